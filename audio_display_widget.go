@@ -35,8 +35,27 @@ type AudioDisplayWidget struct {
 
 	area         image.Rectangle
 	waitingFrame int
+
+	// The boundary of the selected take being dragged with the mouse
+	draggingHandle takeHandle
+	// The document and the take's timespan from before the drag, so that history is only recorded if it moves
+	dragSnapshot docSnapshot
+	dragFrom     TimeSpan
+
+	// The position that was last clicked
+	cursor       time.Duration
+	cursorActive bool
 }
 
+// A draggable boundary of the selected take.
+type takeHandle uint8
+
+const (
+	noHandle    takeHandle = 0
+	startHandle takeHandle = 1
+	endHandle   takeHandle = 2
+)
+
 func (w *AudioDisplayWidget) animateWaiting() {
 	for len(currentSession.Audio) == 0 {
 		w.waitingFrame++
@@ -151,11 +170,11 @@ func (w *AudioDisplayWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) erro
 		return err
 	}
 
-	cells := buffer.NewCells(fmt.Sprintf("%s", Timestamp(&w.window.Start)))
+	cells := buffer.NewCells(rulerLabel(w.window.Start))
 	x, y := 0, w.area.Dy()-1
 	DrawCells(cvs, cells, x, y)
 
-	cells = buffer.NewCells(fmt.Sprintf("%s", Timestamp(&w.window.End)))
+	cells = buffer.NewCells(rulerLabel(w.window.End))
 	x, y = w.area.Dx()-len(cells), w.area.Dy()-1
 	DrawCells(cvs, cells, x, y)
 
@@ -188,7 +207,7 @@ func (w *AudioDisplayWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) erro
 	lowerMidY := w.area.Dy() / 4 * 3
 	if isPlaying {
 		d := samplesToDuration(sampleRate, playbackPosition)
-		cells = buffer.NewCells(fmt.Sprintf("%s", Timestamp(&d)))
+		cells = buffer.NewCells(fmt.Sprintf("%s (%.2fx)", Timestamp(&d), playbackSpeed))
 		x, y = (w.area.Dx()/2)-(len(cells)/2), lowerMidY
 		DrawCells(cvs, cells, x, y)
 	}
@@ -225,6 +244,32 @@ func (w *AudioDisplayWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) erro
 		)
 	}
 
+	for i, t := range currentSession.Doc.syncCandidates {
+		if t < w.window.Start || t > w.window.End {
+			continue
+		}
+		candidateX := timestampOffsetToX(t, w.area, w.window)
+		color := METADATA_COLOR
+		label := ""
+		if i == 0 {
+			label = fmt.Sprintf("sync %.0f%%", currentSession.Doc.SyncConfidence*100)
+			if currentSession.Doc.SyncConfidence < minSyncConfidence {
+				color = BAD_COLOR
+				label += " ambiguous"
+			} else {
+				color = SYNC_OFFSET_COLOR
+			}
+		}
+		cvs.SetAreaCellOpts(
+			image.Rect(candidateX, 2, candidateX+1, cvs.Area().Dy()-4),
+			cell.BgColor(color),
+		)
+		if label != "" {
+			cells = buffer.NewCells(label, cell.FgColor(color))
+			DrawCells(cvs, cells, clamp(candidateX+1, 0, w.area.Dx()-len(cells)), 1)
+		}
+	}
+
 	if currentSession.Doc.SyncOffset >= w.window.Start && currentSession.Doc.SyncOffset <= w.window.End {
 		syncOffsetX := timestampOffsetToX(currentSession.Doc.SyncOffset, w.area, w.window)
 
@@ -238,6 +283,58 @@ func (w *AudioDisplayWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) erro
 			image.Rect(syncOffsetX, 2, syncOffsetX+1, cvs.Area().Dy()-4),
 			cell.BgColor(SYNC_OFFSET_COLOR),
 		)
+		if currentSession.Doc.SyncManual {
+			cells = buffer.NewCells("sync manual", cell.FgColor(SYNC_OFFSET_COLOR))
+			DrawCells(cvs, cells, clamp(syncOffsetX+1, 0, w.area.Dx()-len(cells)), 1)
+		}
+	}
+
+	for _, c := range currentSession.Doc.Cameras {
+		if c.Offset < w.window.Start || c.Offset > w.window.End {
+			continue
+		}
+		cameraX := timestampOffsetToX(c.Offset, w.area, w.window)
+		color := SYNC_OFFSET_COLOR
+		if c.Confidence < minSyncConfidence {
+			color = BAD_COLOR
+		}
+		cvs.SetAreaCellOpts(
+			image.Rect(cameraX, 2, cameraX+1, cvs.Area().Dy()-4),
+			cell.BgColor(color),
+		)
+		cells = buffer.NewCells("sync "+c.Name, cell.FgColor(color))
+		DrawCells(cvs, cells, clamp(cameraX+1, 0, w.area.Dx()-len(cells)), 1)
+	}
+
+	markers := splitPoints
+	if w.cursorActive {
+		markers = append([]time.Duration{w.cursor}, markers...)
+	}
+	for i, t := range markers {
+		if t < w.window.Start || t > w.window.End {
+			continue
+		}
+		color := SPLIT_COLOR
+		if i == 0 && w.cursorActive {
+			color = CURSOR_COLOR
+		}
+		markerX := timestampOffsetToX(t, w.area, w.window)
+		cvs.SetAreaCellOpts(
+			image.Rect(markerX, 1, markerX+1, cvs.Area().Dy()-1),
+			cell.BgColor(color),
+		)
+	}
+
+	if take := getSelectedTake(); take != nil && !isRecordingTake {
+		for _, t := range []time.Duration{take.Start, take.End} {
+			if t >= w.window.Start && t <= w.window.End {
+				handleX := timestampOffsetToX(t, w.area, w.window)
+				cvs.SetAreaCellOpts(
+					image.Rect(handleX, 0, handleX+1, cvs.Area().Dy()),
+					cell.BgColor(SELECT_COLOR),
+				)
+			}
+		}
 	}
 
 	return nil
@@ -257,7 +354,13 @@ func (w *AudioDisplayWidget) Mouse(m *terminalapi.Mouse) error {
 	if m.Button == mouse.ButtonRight {
 		w.selectionActive = false
 	} else if m.Button == mouse.ButtonLeft {
-		if w.selectionActive {
+		if w.draggingHandle != noHandle {
+			w.dragHandle(m.Position)
+		} else if h := w.handleAt(m.Position); !w.dragging && h != noHandle {
+			w.dragSnapshot = snapshotDocument("Drag Take Boundary", &currentSession.Doc)
+			w.dragFrom = getSelectedTake().TimeSpan
+			w.draggingHandle = h
+		} else if w.selectionActive {
 			if w.dragging {
 				startPoint := mousePointToTimestampOffset(w.lastClickStart, w.area, w.window)
 				dragPoint := mousePointToTimestampOffset(m.Position, w.area, w.window)
@@ -277,6 +380,8 @@ func (w *AudioDisplayWidget) Mouse(m *terminalapi.Mouse) error {
 			w.selected = TimeSpan{
 				Start: mousePointToTimestampOffset(m.Position, w.area, w.window),
 			}
+			w.cursor = w.selected.Start
+			w.cursorActive = true
 			log.Printf("drag select start %s", w.selected.Start)
 			w.lastClickStart = m.Position
 		}
@@ -312,7 +417,16 @@ func (w *AudioDisplayWidget) Mouse(m *terminalapi.Mouse) error {
 		}
 
 	} else if m.Button == mouse.ButtonRelease {
-		if w.selectionActive && w.dragging {
+		if w.draggingHandle != noHandle {
+			w.draggingHandle = noHandle
+			if take := getSelectedTake(); take != nil && take.TimeSpan != w.dragFrom {
+				pushHistory(w.dragSnapshot)
+				if err := resyncEditedTake(take); err != nil {
+					setStatus("%s", err)
+				}
+				currentSession.FullSave()
+			}
+		} else if w.selectionActive && w.dragging {
 			w.dragging = false
 			if m.Position == w.lastClickStart {
 				w.selectionActive = false
@@ -336,6 +450,47 @@ func (w *AudioDisplayWidget) Options() widgetapi.Options {
 	}
 }
 
+// Returns the boundary of the selected take at the point, if there is one.
+func (w *AudioDisplayWidget) handleAt(p image.Point) takeHandle {
+	take := getSelectedTake()
+	if take == nil || isRecordingTake {
+		return noHandle
+	}
+	handles := []takeHandle{startHandle, endHandle}
+	for i, t := range []time.Duration{take.Start, take.End} {
+		h := handles[i]
+		if t < w.window.Start || t > w.window.End {
+			continue
+		}
+		x := timestampOffsetToX(t, w.area, w.window)
+		if p.X >= x-1 && p.X <= x+1 {
+			return h
+		}
+	}
+	return noHandle
+}
+
+func (w *AudioDisplayWidget) dragHandle(p image.Point) {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	t := mousePointToTimestampOffset(p, w.area, w.window)
+	if w.draggingHandle == startHandle && t >= 0 && t < take.End {
+		take.Start = t
+	} else if w.draggingHandle == endHandle && t > take.Start && t <= samplesToDuration(sampleRate, len(currentSession.Audio)) {
+		take.End = t
+	}
+}
+
+// Labels a position in the recording with its timecode if timecodes are enabled, otherwise with its timestamp.
+func rulerLabel(t time.Duration) string {
+	if timecodeRate == nil {
+		return Timestamp(&t)
+	}
+	return formatSyncedTime(currentSession.Doc.SyncedTime(t))
+}
+
 func mousePointToTimestampOffset(p image.Point, area image.Rectangle, window TimeSpan) time.Duration {
 	return window.Start + window.Duration()*time.Duration(p.X)/time.Duration(area.Dx())
 }
@@ -344,6 +499,39 @@ func timestampOffsetToX(timestamp time.Duration, area image.Rectangle, window Ti
 	return int(timestamp-window.Start) * area.Dx() / int(window.Duration())
 }
 
+// Returns the position that was last clicked, if there is one.
+func (w *AudioDisplayWidget) Cursor() (time.Duration, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.cursor, w.cursorActive
+}
+
+// Moves the viewport so the timespan is in the middle of it, zooming out if it doesn't fit.
+func (w *AudioDisplayWidget) CenterOn(timespan TimeSpan) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stickToEnd = false
+	length := w.window.Duration()
+	if timespan.Duration()*5/4 > length {
+		length = timespan.Duration() * 5 / 4
+	}
+	middle := timespan.Start + timespan.Duration()/2
+	w.window.Start = middle - length/2
+	w.window.End = w.window.Start + length
+
+	recorded := samplesToDuration(sampleRate, len(currentSession.Audio))
+	if w.window.End > recorded {
+		w.window.Start -= w.window.End - recorded
+		w.window.End = recorded
+	}
+	if w.window.Start < 0 {
+		w.window.End -= w.window.Start
+		w.window.Start = 0
+	}
+}
+
 func (w *AudioDisplayWidget) Deselect() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
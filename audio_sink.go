@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"github.com/gordonklaus/portaudio"
+)
+
+// Destination for audio that is played back. Samples are mono, at sampleRate.
+type AudioSink interface {
+	// Write blocks until the sink is ready for more audio.
+	Write(samples []int32) error
+	Close() error
+}
+
+// Selects the sink used for playback. Either "portaudio", "null", or "file:<path to wav>".
+var playbackSinkName string = "portaudio"
+
+func openPlaybackSink() (AudioSink, error) {
+	switch {
+	case playbackSinkName == "portaudio":
+		return NewPortAudioSink()
+	case playbackSinkName == "null":
+		return &NullSink{}, nil
+	case strings.HasPrefix(playbackSinkName, "file:"):
+		return NewWavFileSink(unusedPath(strings.TrimPrefix(playbackSinkName, "file:")))
+	}
+	return nil, errors.New("Unknown playback sink: " + playbackSinkName)
+}
+
+// Returns the path, or if a file already exists there, the path numbered with the first free number, so that every
+// playback to a file is kept.
+func unusedPath(p string) string {
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			return p
+		}
+		p = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// Plays audio through the default output device.
+type PortAudioSink struct {
+	stream *portaudio.Stream
+	out    []int32
+}
+
+func NewPortAudioSink() (*PortAudioSink, error) {
+	// This is based on the play example shown in the portaudio repo.
+	const bufSize = 1024
+
+	if !portaudioInitialized {
+		err := initPortAudio()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &PortAudioSink{
+		out: make([]int32, bufSize),
+	}
+	stream, err := portaudio.OpenDefaultStream(0, 1, sampleRate, len(s.out), &s.out)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Start()
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+	s.stream = stream
+	return s, nil
+}
+
+func (s *PortAudioSink) Write(samples []int32) error {
+	s.out = samples
+	err := s.stream.Write()
+	if err != nil {
+		// Underflows are not fatal, playback can continue.
+		log.Printf("Failed to write stream audio: %v", err)
+	}
+	return nil
+}
+
+func (s *PortAudioSink) Close() error {
+	s.stream.Stop()
+	return s.stream.Close()
+}
+
+// Time between audio being written and it coming out of the output device.
+func (s *PortAudioSink) Latency() time.Duration {
+	if info := s.stream.Info(); info != nil {
+		return info.OutputLatency
+	}
+	return 0
+}
+
+// Discards all audio written to it, without waiting. Useful when there is no output device.
+type NullSink struct {
+	// Number of samples written to the sink.
+	Written int
+}
+
+func (s *NullSink) Write(samples []int32) error {
+	s.Written += len(samples)
+	return nil
+}
+
+func (s *NullSink) Close() error {
+	return nil
+}
+
+// Writes audio to a wav file instead of playing it.
+type WavFileSink struct {
+	file    *os.File
+	encoder *wav.Encoder
+}
+
+func NewWavFileSink(path string) (*WavFileSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &WavFileSink{
+		file:    f,
+		encoder: wav.NewEncoder(f, sampleRate, 32, 1, 1),
+	}, nil
+}
+
+func (s *WavFileSink) Write(samples []int32) error {
+	buf := audio.PCMBuffer{
+		Format:         audio.FormatMono44100,
+		DataType:       audio.DataTypeI32,
+		SourceBitDepth: 32,
+		I32:            samples,
+	}
+	return s.encoder.Write(buf.AsIntBuffer())
+}
+
+func (s *WavFileSink) Close() error {
+	err := s.encoder.Close()
+	if err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
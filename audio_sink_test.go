@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-audio/wav"
+)
+
+func TestPlaySamplesNullSink(t *testing.T) {
+	samples := sineWave(440, sampleRate*2)
+	sink := &NullSink{}
+	err := playSamples(sink, samples, TimeSpan{Start: 500 * time.Millisecond, End: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sink.Written != sampleRate/2 {
+		t.Errorf("Expected %d samples to be played, got %d", sampleRate/2, sink.Written)
+	}
+	if isPlaying {
+		t.Errorf("Expected playback to be finished")
+	}
+}
+
+func TestPlaySamplesWavFileSink(t *testing.T) {
+	samples := sineWave(440, sampleRate)
+	path := filepath.Join(t.TempDir(), "playback.wav")
+	sink, err := NewWavFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = playSamples(sink, samples, TimeSpan{Start: 0, End: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	buf, err := wav.NewDecoder(f).FullPCMBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf.Data) != len(samples) {
+		t.Fatalf("Expected %d samples in file, got %d", len(samples), len(buf.Data))
+	}
+	for i := range samples {
+		if int32(buf.Data[i]) != samples[i] {
+			t.Fatalf("Sample %d differs: %d != %d", i, buf.Data[i], samples[i])
+		}
+	}
+}
+
+// Records the playback position whenever audio is written to it.
+type positionSink struct {
+	positions []int
+}
+
+func (s *positionSink) Write(samples []int32) error {
+	s.positions = append(s.positions, playbackPosition)
+	return nil
+}
+
+func (s *positionSink) Close() error {
+	return nil
+}
+
+func TestPlaybackPositionAtSpeed(t *testing.T) {
+	defer func(speed float64) { playbackSpeed = speed }(playbackSpeed)
+	playbackSpeed = 2
+	samples := sineWave(440, sampleRate*2)
+
+	// long enough to be stretched, and too short to be, which plays at normal speed
+	for _, n := range []int{sampleRate / 2, stretchFrameSize*2 - 1} {
+		start := sampleRate
+		sink := &positionSink{}
+		err := playSamples(sink, samples, TimeSpan{Start: samplesToDuration(sampleRate, start), End: samplesToDuration(sampleRate, start+n)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range sink.positions {
+			if p < start || p >= start+n || (i > 0 && p <= sink.positions[i-1]) {
+				t.Errorf("Playing %d samples: position %d out of the played segment: %v", n, p, sink.positions)
+				break
+			}
+		}
+	}
+}
+
+func TestUnusedPath(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "playback.wav")
+	if unusedPath(p) != p {
+		t.Errorf("Expected a free path to be used as is")
+	}
+	for _, name := range []string{"playback.wav", "playback-2.wav"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := unusedPath(p); got != filepath.Join(dir, "playback-3.wav") {
+		t.Errorf("Expected the first free number, got %s", got)
+	}
+}
@@ -59,6 +59,9 @@ func (w *ChunkListWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) error {
 			}
 			cells := buffer.NewCells(chunk.Content, cell.FgColor(color))
 
+			if chunk.CircledTake() >= 0 {
+				cvs.SetCell(image.Point{X: 0, Y: cur.Y}, '◉', cell.FgColor(GOOD_COLOR))
+			}
 			cur.X = 1
 			lim := clamp(width-cur.X, cur.X, len(cells))
 			if lim < 0 {
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Number of recent observations used to estimate when recording started.
+const clockObservations = 64
+
+// Correlates the monotonic clock with the position in the recorded audio, so the sample that was
+// being captured at any moment is known, even before the buffer containing it has been processed.
+type sampleClock struct {
+	mu sync.Mutex
+	// Estimates of when the first sample was captured, one for each recent observation.
+	origins []time.Time
+	next    int
+}
+
+var recordingClock sampleClock
+
+// Records that the last of the samples read so far was captured by the ADC at the given time.
+func (c *sampleClock) Observe(samplesRead int, capturedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	origin := capturedAt.Add(-samplesToDuration(sampleRate, samplesRead))
+	if len(c.origins) < clockObservations {
+		c.origins = append(c.origins, origin)
+	} else {
+		c.origins[c.next] = origin
+		c.next = (c.next + 1) % clockObservations
+	}
+}
+
+// Returns the index of the sample that was captured at the given time.
+// Returns false if nothing has been observed yet.
+func (c *sampleClock) SampleAt(t time.Time) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.origins) == 0 {
+		return 0, false
+	}
+	// Reading audio can only be delayed, never early, so the earliest estimate is the most accurate.
+	origin := c.origins[0]
+	for _, o := range c.origins[1:] {
+		if o.Before(origin) {
+			origin = o
+		}
+	}
+	return durationToSamples(sampleRate, t.Sub(origin)), true
+}
+
+func (c *sampleClock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.origins = nil
+	c.next = 0
+}
+
+// Time to subtract from take boundaries, to account for the delay between pressing a key and it being handled.
+var inputLatencyCompensation time.Duration = 0
+
+// Returns the timestamp in the recorded audio at which a key was pressed just now.
+func keypressTimestamp() time.Duration {
+	n, ok := recordingClock.SampleAt(time.Now())
+	if !ok {
+		n = len(currentSession.Audio)
+	}
+	t := samplesToDuration(sampleRate, n) - inputLatencyCompensation
+	if t < 0 {
+		return 0
+	}
+	return t
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleClock(t *testing.T) {
+	var c sampleClock
+	if _, ok := c.SampleAt(time.Now()); ok {
+		t.Errorf("Expected clock without observations to be invalid")
+	}
+
+	start := time.Now()
+	jitter := []time.Duration{3 * time.Millisecond, 0, 7 * time.Millisecond, 1 * time.Millisecond}
+	read := 0
+	for i := 0; i < 100; i++ {
+		read += 1024
+		captured := start.Add(samplesToDuration(sampleRate, read)).Add(jitter[i%len(jitter)])
+		c.Observe(read, captured)
+	}
+
+	n, ok := c.SampleAt(start.Add(time.Second))
+	if !ok {
+		t.Fatal("Expected clock to be valid")
+	}
+	if n < sampleRate-1 || n > sampleRate+1 {
+		t.Errorf("Expected sample %d, got %d", sampleRate, n)
+	}
+
+	c.Reset()
+	if _, ok := c.SampleAt(time.Now()); ok {
+		t.Errorf("Expected reset clock to be invalid")
+	}
+}
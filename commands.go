@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Runs the commands given on the command line on a finished session, instead of recording.
+func runSessionCommands(id int, scriptPath string, exportFormats string) error {
+	err := readScript(scriptPath)
+	if err != nil {
+		return fmt.Errorf("Failed to open file %s: %s", scriptPath, err)
+	}
+	currentSession, err = loadSession(id, currentSession.Doc)
+	if err != nil {
+		return fmt.Errorf("Failed to load session %d: %s", id, err)
+	}
+
+	if syncReferencePath != "" {
+		ref, err := readReferenceAudio(syncReferencePath)
+		if err != nil {
+			return err
+		}
+		quality, err := currentSession.syncToReference(ref)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Synced to reference: sync offset %s, quality %.0f%%\n", Timestamp(&currentSession.Doc.SyncOffset), quality*100)
+		err = currentSession.FullSave()
+		if err != nil {
+			return err
+		}
+	}
+
+	if ltcFilePath != "" {
+		samples, err := readLTCFile(ltcFilePath)
+		if err != nil {
+			return err
+		}
+		track := buildLTCTrack(decodeLTC(samples))
+		if track == nil {
+			return fmt.Errorf("No timecode found in %s", ltcFilePath)
+		}
+		currentSession.Doc.LTC = track
+		fmt.Printf("Decoded %s fps timecode starting at %s, with %d discontinuities\n", track.Rate, track.Rate.timecode(track.Segments[0].StartFrame), len(track.Segments)-1)
+		err = currentSession.FullSave()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range strings.Split(exportFormats, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		e, err := findExporter(name)
+		if err != nil {
+			return err
+		}
+		p, err := currentSession.export(e)
+		if err != nil {
+			return fmt.Errorf("Failed to export %s: %s", name, err)
+		}
+		fmt.Printf("Exported %s\n", p)
+	}
+
+	return nil
+}
+
+var isSyncingToReference bool = false
+
+// Syncs the session being recorded to the reference audio, in the background.
+func syncRecordingToReference() {
+	if isSyncingToReference {
+		return
+	}
+	isSyncingToReference = true
+	defer func() {
+		isSyncingToReference = false
+	}()
+	setStatus("Syncing to reference audio...")
+
+	ref, err := readReferenceAudio(syncReferencePath)
+	if err != nil {
+		setStatus("Failed to read reference audio: %s", err)
+		return
+	}
+	// the recording keeps growing, so sync against what has been recorded so far
+	recorded := Session{
+		Audio: currentSession.Audio[:len(currentSession.Audio)],
+	}
+	quality, err := recorded.syncToReference(ref)
+	if err != nil {
+		setStatus("Failed to sync to reference audio: %s", err)
+		return
+	}
+
+	recordHistory("Sync to Reference")
+	currentSession.Doc.SyncOffset = recorded.Doc.SyncOffset
+	currentSession.Doc.SyncConfidence = recorded.Doc.SyncConfidence
+	currentSession.Doc.SyncDrift = recorded.Doc.SyncDrift
+	currentSession.Doc.SyncManual = false
+	currentSession.Doc.syncCandidates = recorded.Doc.syncCandidates
+	currentSession.FullSave()
+	setStatus("Synced to reference audio: sync offset %s, quality %.0f%%", Timestamp(&currentSession.Doc.SyncOffset), quality*100)
+}
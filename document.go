@@ -2,7 +2,9 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,10 +18,20 @@ const (
 	// Denotes a bad take.
 	Bad TakeMark = 2
 	// Used to denote a timespan where an audio sync peak, usually created with a clap or clapperboard, can be found.
-	// There can be multiple Sync takes, but only the first one will be used to determine the sync offset.
+	// There can be multiple Sync takes. The first one is used to determine the sync offset, and the last one
+	// with a camera time is used to correct for clock drift.
 	Sync TakeMark = 3
 )
 
+func parseTakeMark(s string) (TakeMark, error) {
+	for _, m := range []TakeMark{Unmarked, Good, Bad, Sync} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return Unmarked, errors.New("Unknown take mark: " + s)
+}
+
 // Metadata prefixes used in scripts. They should be omited from selectable chunks.
 func getMetaPrefixes() []string {
 	return []string{"TODO", "REF", "NOTE", "BIT"}
@@ -39,11 +51,94 @@ func (m TakeMark) String() string {
 	return "unknown"
 }
 
+// Splits a comma separated list of tags, dropping empty tags.
+func parseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 type Document struct {
 	headers   []Header
 	syncTakes []Take
 	// Presice timestamp of the audio sync peak
 	SyncOffset time.Duration
+	// Ratio between time passing on the camera and in the recorded audio, or 0 if drift is not corrected.
+	SyncDrift float64
+	// How clearly the sync peak stood out from other transients in the sync take, from 0 to 1.
+	SyncConfidence float64
+	// Whether the sync offset was placed by hand instead of detected.
+	SyncManual bool
+	// The sync peaks of cameras other than the main one.
+	Cameras []CameraSync
+	// Timecode decoded from linear timecode recorded with the session, if any.
+	LTC *LTCTrack
+	// Transients in the sync take that could be the sync peak, the detected sync peak first.
+	syncCandidates []time.Duration
+}
+
+// Converts a timestamp in the recorded audio to a timestamp relative to the audio sync peak,
+// corrected for the clock drift between the camera and the audio interface.
+func (doc *Document) SyncedTime(t time.Duration) time.Duration {
+	synced := t - doc.SyncOffset
+	if doc.SyncDrift != 0 {
+		synced = time.Duration(float64(synced) * doc.SyncDrift)
+	}
+	return synced
+}
+
+// Converts a timestamp relative to the audio sync peak back to a timestamp in the recorded audio.
+func (doc *Document) UnsyncedTime(t time.Duration) time.Duration {
+	if doc.SyncDrift != 0 {
+		t = time.Duration(float64(t) / doc.SyncDrift)
+	}
+	return t + doc.SyncOffset
+}
+
+// The sync peak of a named camera, detected in the first sync take for that camera.
+type CameraSync struct {
+	Name string
+	// Presice timestamp of the audio sync peak
+	Offset     time.Duration
+	Confidence float64
+}
+
+// Converts a timestamp in the recorded audio to a timestamp relative to the sync peak of the named camera.
+// The main camera has no name. Returns false if there is no camera with the name.
+func (doc *Document) SyncedTimeFor(camera string, t time.Duration) (time.Duration, bool) {
+	if camera == "" {
+		return doc.SyncedTime(t), true
+	}
+	for _, c := range doc.Cameras {
+		if c.Name == camera {
+			return t - c.Offset, true
+		}
+	}
+	return 0, false
+}
+
+// Returns the index of the first sync take for the camera, or -1 if there is none.
+func (doc *Document) firstSyncTake(camera string) int {
+	for i, take := range doc.syncTakes {
+		if take.Camera == camera {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the timespan of the sync take used to determine the sync offset.
+func (doc *Document) firstSyncSpan() (TimeSpan, bool) {
+	i := doc.firstSyncTake("")
+	if i < 0 {
+		return TimeSpan{}, false
+	}
+	return doc.syncTakes[i].TimeSpan, true
 }
 
 func (doc *Document) CountChunks() int {
@@ -54,6 +149,20 @@ func (doc *Document) CountChunks() int {
 	return c
 }
 
+// Finds a chunk by the text of its header, and its index within that header, as written in takes.csv.
+func (doc *Document) findChunk(header string, index string) (*Chunk, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid chunk index: %s", index)
+	}
+	for _, h := range doc.headers {
+		if h.Text == header && i >= 0 && i < len(h.Chunks) {
+			return &h.Chunks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("Chunk %d of header %q is not in the script", i, header)
+}
+
 func (doc *Document) GetChunk(index int) *Chunk {
 	for _, h := range doc.headers {
 		if index-len(h.Chunks) < 0 {
@@ -83,6 +192,40 @@ type Chunk struct {
 	Takes   []Take
 }
 
+// Returns the index of the circled take, or -1 if no take is circled.
+func (c *Chunk) CircledTake() int {
+	for i, t := range c.Takes {
+		if t.Circled {
+			return i
+		}
+	}
+	return -1
+}
+
+// Circles the take, uncircling any other take, so that at most one take of the chunk is circled.
+// Circling the take that is already circled uncircles it.
+func (c *Chunk) ToggleCircle(index int) {
+	circle := !c.Takes[index].Circled
+	for i := range c.Takes {
+		c.Takes[i].Circled = false
+	}
+	c.Takes[index].Circled = circle
+}
+
+// Returns the index of the take that should be used in the edit, which is the circled take,
+// or the last good take if no take is circled. Returns -1 if the chunk has no usable take.
+func (c *Chunk) ChosenTake() int {
+	if i := c.CircledTake(); i >= 0 {
+		return i
+	}
+	for i := len(c.Takes) - 1; i >= 0; i-- {
+		if c.Takes[i].Mark == Good {
+			return i
+		}
+	}
+	return -1
+}
+
 // A chunk that contains content that should not be selectable for takes.
 type MetaChunk struct {
 	Content string
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChosenTake(t *testing.T) {
+	chunk := Chunk{
+		Takes: []Take{
+			{Mark: Good},
+			{Mark: Bad},
+			{Mark: Good},
+			{Mark: Unmarked},
+		},
+	}
+	if c := chunk.ChosenTake(); c != 2 {
+		t.Errorf("Expected last good take to be chosen, got %d", c)
+	}
+
+	chunk.ToggleCircle(1)
+	if c := chunk.ChosenTake(); c != 1 {
+		t.Errorf("Expected circled take to be chosen, got %d", c)
+	}
+
+	chunk = Chunk{
+		Takes: []Take{
+			{Mark: Bad},
+			{Mark: Unmarked},
+		},
+	}
+	if c := chunk.ChosenTake(); c != -1 {
+		t.Errorf("Expected no take to be chosen, got %d", c)
+	}
+}
+
+func TestToggleCircle(t *testing.T) {
+	chunk := Chunk{
+		Takes: []Take{{}, {}, {}},
+	}
+	chunk.ToggleCircle(0)
+	chunk.ToggleCircle(2)
+	if chunk.CircledTake() != 2 || chunk.Takes[0].Circled {
+		t.Errorf("Expected only the last circled take to be circled")
+	}
+	chunk.ToggleCircle(2)
+	if chunk.CircledTake() != -1 {
+		t.Errorf("Expected take to be uncircled")
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags := parseTags(" happy read,, alt emphasis ,")
+	if !reflect.DeepEqual(tags, []string{"happy read", "alt emphasis"}) {
+		t.Errorf("Incorrect tags: %v", tags)
+	}
+
+	if tags := parseTags("  "); len(tags) != 0 {
+		t.Errorf("Expected no tags, got %v", tags)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// CMX3600 event numbers have 3 digits.
+	maxEDLEvents = 999
+	// Reel name for sources that are named by a FROM CLIP NAME comment instead.
+	edlAuxReel = "AX"
+)
+
+// Writes a CMX3600 EDL with an event for the chosen take of every chunk, in script order, laid out back to back.
+// Source timecodes are the synced times of the takes for the exported camera from the start timecode, so that they
+// line up with the camera footage.
+func writeEDL(w io.Writer, s *Session) error {
+	clips, err := s.programClips(false)
+	if err != nil {
+		return err
+	}
+	if len(clips) > maxEDLEvents {
+		return fmt.Errorf("An EDL can only have %d events, but %d takes are chosen", maxEDLEvents, len(clips))
+	}
+	rate := exportFrameRate()
+	fcm := "NON-DROP FRAME"
+	if rate.dropFrame {
+		fcm = "DROP FRAME"
+	}
+	rec, err := rate.parseTimecode(timelineStart)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "TITLE: Session %d\n", s.Id)
+	fmt.Fprintf(w, "FCM: %s\n", fcm)
+	for i, c := range clips {
+		in := exportFrame(c.Synced.Start)
+		out := exportFrame(c.Synced.End)
+		if out <= in {
+			out = in + 1
+		}
+		fmt.Fprintf(w, "\n%03d  %-8s %-6s%-9s%s %s %s %s\n", i+1, edlAuxReel, "A", "C",
+			rate.timecode(in), rate.timecode(out), rate.timecode(rec), rate.timecode(rec+out-in))
+		fmt.Fprintf(w, "* FROM CLIP NAME: audio.wav\n")
+		fmt.Fprintf(w, "* COMMENT: %s CHUNK %d TAKE %d %s\n", c.Header, c.HeaderChunk, c.TakeIndex, strings.ToUpper(c.Take.Mark.String()))
+		for _, line := range strings.Split(c.Content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(w, "* COMMENT: %s\n", line)
+			}
+		}
+		rec += out - in
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteEDL(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+
+	var b bytes.Buffer
+	if err := writeEDL(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	// source timecodes are synced 1 second into the recording, from the start timecode
+	expected := `TITLE: Session 7
+FCM: NON-DROP FRAME
+
+001  AX       A     C        10:00:01:00 10:00:03:00 01:00:00:00 01:00:02:00
+* FROM CLIP NAME: audio.wav
+* COMMENT: Intro CHUNK 0 TAKE 0 GOOD
+* COMMENT: Hello there.
+
+002  AX       A     C        10:00:11:00 10:00:12:12 01:00:02:00 01:00:03:12
+* FROM CLIP NAME: audio.wav
+* COMMENT: Outro CHUNK 0 TAKE 1 BAD
+* COMMENT: Goodbye.
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, b.String())
+	}
+
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A format that sessions can be exported to, for editing them in other programs.
+type exporter struct {
+	name      string
+	extension string
+	write     func(w io.Writer, s *Session) error
+}
+
+var exporters = []exporter{
+	{name: "edl", extension: "edl", write: writeEDL},
+	{name: "fcpxml", extension: "fcpxml", write: writeFCPXML},
+	{name: "xmeml", extension: "xml", write: writeXMEML},
+	{name: "otio", extension: "otio", write: writeOTIO},
+	{name: "audacity", extension: "txt", write: writeAudacityLabels},
+	{name: "reaper", extension: "rpp", write: writeReaperProject},
+}
+
+// Timecode that exported timelines start at, by convention.
+const timelineStart = "01:00:00:00"
+
+// Camera that exported timings are relative to, or empty for the main camera.
+var exportCamera string
+
+func findExporter(name string) (exporter, error) {
+	for _, e := range exporters {
+		if e.name == name {
+			return e, nil
+		}
+	}
+	return exporter{}, fmt.Errorf("Unknown export format %q, expected one of %s", name, exporterNames())
+}
+
+func exporterNames() string {
+	var names []string
+	for _, e := range exporters {
+		names = append(names, e.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Exports the session to a file in the session's folder, and returns the path of the file.
+func (s *Session) export(e exporter) (string, error) {
+	dir, err := s.getSessionDir()
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkExportCamera(); err != nil {
+		return "", err
+	}
+	name := "session"
+	if exportCamera != "" {
+		name += "-" + exportCamera
+	}
+	p := path.Join(dir, name+"."+e.extension)
+	f, err := os.Create(p)
+	if err != nil {
+		return "", err
+	}
+	err = e.write(f, s)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	return p, f.Close()
+}
+
+// A take that was chosen for the edit, or rejected if it is marked bad.
+type programClip struct {
+	// Title of the header the chunk is under
+	Header string
+	// Index of the chunk in the document
+	Chunk int
+	// Index of the chunk under its header
+	HeaderChunk int
+	TakeIndex   int
+	Take        Take
+	Content     string
+	// The timespan of the take relative to the sync peak of the exported camera
+	Synced TimeSpan
+	// Frames of the source timecodes of the take, which clips of the session audio are timed by
+	In, Out  int
+	Rejected bool
+}
+
+// Whether exports that can disable clips include the takes marked bad.
+var exportRejected bool
+
+// Returns the chosen take of every chunk that has one, in script order. Rejected takes are included with the chosen
+// take of their chunk, in the order they were recorded, if includeRejected is set.
+func (s *Session) programClips(includeRejected bool) ([]programClip, error) {
+	if err := s.checkExportCamera(); err != nil {
+		return nil, err
+	}
+	_, shift := s.mediaStartFrame()
+	var clips []programClip
+	chunk := 0
+	for _, header := range s.Doc.headers {
+		for c := range header.Chunks {
+			ch := &header.Chunks[c]
+			chosen := ch.ChosenTake()
+			for t, take := range ch.Takes {
+				rejected := t != chosen
+				if rejected && (!includeRejected || take.Mark != Bad) {
+					continue
+				}
+				start, _ := s.Doc.SyncedTimeFor(exportCamera, take.Start)
+				end, _ := s.Doc.SyncedTimeFor(exportCamera, take.End)
+				clips = append(clips, programClip{
+					Header:      headerTitle(header.Text),
+					Chunk:       chunk,
+					HeaderChunk: c,
+					TakeIndex:   t,
+					Take:        take,
+					Content:     ch.Content,
+					Synced:      TimeSpan{Start: start, End: end},
+					In:          exportFrame(start) + shift,
+					Out:         exportFrame(end) + shift,
+					Rejected:    rejected,
+				})
+			}
+			chunk++
+		}
+	}
+	return clips, nil
+}
+
+// Returns an error if the exported camera has not been synced, since every export is timed relative to it.
+func (s *Session) checkExportCamera() error {
+	if _, ok := s.Doc.SyncedTimeFor(exportCamera, 0); !ok {
+		return fmt.Errorf("There is no sync take for camera %s", exportCamera)
+	}
+	return nil
+}
+
+// Returns the frame of the timecode that the session audio starts at, for the exported camera. Source timecodes are
+// the export timecodes of synced times, moved on by whole days if the session audio would otherwise start before
+// midnight, since media can't start at a negative timecode. Returns the frames they are moved on by too.
+func (s *Session) mediaStartFrame() (start, shift int) {
+	synced, _ := s.Doc.SyncedTimeFor(exportCamera, 0)
+	start = exportFrame(synced)
+	if start < 0 {
+		day := exportFrameRate().framesPerDay()
+		shift = (-start + day - 1) / day * day
+	}
+	return start + shift, shift
+}
+
+// Returns the absolute path of the session audio, for formats that reference it.
+func (s *Session) audioPath() (string, error) {
+	dir, err := s.getSessionDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path.Join(dir, "audio.wav"))
+}
+
+// A clip placed on an exported timeline.
+type timelineClip struct {
+	programClip
+	// Frame the clip starts at, from the start of the timeline
+	Start int
+}
+
+func (c *timelineClip) End() int {
+	return c.Start + c.Out - c.In
+}
+
+// Lays out the chosen takes back to back. Rejected takes are laid out separately, so that the program is the same
+// with or without them, each starting at the position of its chunk's chosen take, or after the previous rejected
+// take if they would overlap. Returns the duration of the timeline.
+func layoutClips(clips []programClip) (program, rejected []timelineClip, duration int) {
+	offset, rejectedOffset := 0, 0
+	lastChunk := -1
+	chunkStart := 0
+	for _, c := range clips {
+		if c.Chunk != lastChunk {
+			chunkStart = offset
+			lastChunk = c.Chunk
+		}
+		if c.Out <= c.In {
+			c.Out = c.In + 1
+		}
+		tc := timelineClip{programClip: c}
+		if c.Rejected {
+			if rejectedOffset < chunkStart {
+				rejectedOffset = chunkStart
+			}
+			tc.Start = rejectedOffset
+			rejectedOffset = tc.End()
+			rejected = append(rejected, tc)
+			continue
+		}
+		tc.Start = offset
+		offset = tc.End()
+		program = append(program, tc)
+	}
+	if rejectedOffset > offset {
+		return program, rejected, rejectedOffset
+	}
+	return program, rejected, offset
+}
+
+// Returns the file URL of the session audio, for formats that reference it.
+func (s *Session) audioURL() (string, error) {
+	p, err := s.audioPath()
+	if err != nil {
+		return "", err
+	}
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		// windows drive letters
+		p = "/" + p
+	}
+	u := url.URL{Scheme: "file", Path: p}
+	return u.String(), nil
+}
+
+// Describes the take's mark, whether it is circled, its rating in stars like the take list, and its tags, for formats
+// that can tag clips.
+func takeKeywords(take Take) []string {
+	var keywords []string
+	if take.Mark != Unmarked {
+		keywords = append(keywords, take.Mark.String())
+	}
+	if take.Circled {
+		keywords = append(keywords, "circled")
+	}
+	if take.Rating > 0 {
+		keywords = append(keywords, strings.Repeat("★", int(take.Rating)))
+	}
+	return append(keywords, take.Tags...)
+}
+
+// Returns the text of a markdown header without the leading #s.
+func headerTitle(text string) string {
+	return strings.TrimSpace(strings.TrimLeft(text, "#"))
+}
+
+// The frame rate of exported timecodes: the timecode frame rate if one is set, otherwise the closest non drop frame
+// rate to the video frame rate.
+func exportFrameRate() frameRate {
+	if timecodeRate != nil {
+		return *timecodeRate
+	}
+	best := frameRates[0]
+	for _, r := range frameRates {
+		if !r.dropFrame && math.Abs(r.fps()-videoFrameRate) < math.Abs(best.fps()-videoFrameRate) {
+			best = r
+		}
+	}
+	return best
+}
+
+// Returns the frame count of the timecode of a synced time in exports.
+func exportFrame(synced time.Duration) int {
+	return startTimecode + exportFrameRate().durationToFrames(synced)
+}
+
+// Exports the session to every format, reporting where the files were written.
+func exportAll() {
+	var written []string
+	for _, e := range exporters {
+		p, err := currentSession.export(e)
+		if err != nil {
+			setStatus("Failed to export %s: %s", e.name, err)
+			return
+		}
+		written = append(written, p)
+	}
+	setStatus("Exported %s", strings.Join(written, ", "))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Sets up a session with takes chosen in different ways, synced 1 second into the recording.
+func exportTestSession() {
+	currentSession = Session{
+		Id:  7,
+		Doc: parseDoc("# Intro\nHello there.\n\nSecond line\nof the intro.\n# Outro\nGoodbye."),
+	}
+	currentSession.Doc.SyncOffset = time.Second
+	currentSession.Doc.GetChunk(0).Takes = []Take{
+		{TimeSpan: TimeSpan{Start: 2 * time.Second, End: 4 * time.Second}, Mark: Good},
+		{TimeSpan: TimeSpan{Start: 5 * time.Second, End: 7 * time.Second}, Mark: Bad},
+	}
+	currentSession.Doc.GetChunk(1).Takes = []Take{
+		{TimeSpan: TimeSpan{Start: 8 * time.Second, End: 9 * time.Second}, Mark: Unmarked},
+	}
+	currentSession.Doc.GetChunk(2).Takes = []Take{
+		{TimeSpan: TimeSpan{Start: 10 * time.Second, End: 11 * time.Second}, Mark: Good},
+		{TimeSpan: TimeSpan{Start: 12 * time.Second, End: 13500 * time.Millisecond}, Mark: Bad, Circled: true},
+	}
+}
+
+// Sets up the export test session in a temporary working directory, with 15 seconds of audio. The working
+// directory and the export settings are restored when the test ends.
+func useExportTestSession(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Error(err)
+		}
+		exportCamera, exportRejected = "", false
+		timecodeRate, startTimecode = nil, 0
+	})
+	exportTestSession()
+	currentSession.Audio = make([]int32, sampleRate*15)
+}
+
+func TestLayoutClips(t *testing.T) {
+	clips := []programClip{
+		{Chunk: 0, In: 25, Out: 50},
+		{Chunk: 1, In: 75, Out: 125, Rejected: true},
+		{Chunk: 1, In: 150, Out: 225, Rejected: true},
+		{Chunk: 1, In: 250, Out: 275},
+		{Chunk: 2, In: 300, Out: 325, Rejected: true},
+	}
+	program, rejected, duration := layoutClips(clips)
+	var starts []int
+	for _, c := range append(program, rejected...) {
+		starts = append(starts, c.Start)
+	}
+	// rejected takes of a chunk follow each other instead of overlapping, and the next chunk's wait for them
+	if !reflect.DeepEqual(starts, []int{0, 25, 25, 75, 150}) {
+		t.Errorf("Incorrect clip starts: %v", starts)
+	}
+	if duration != 175 {
+		t.Errorf("Expected the timeline to end with the last rejected take, got %d", duration)
+	}
+}
+
+func TestProgramClipsDrift(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+	currentSession.Doc.SyncDrift = 1.01
+
+	clips, err := currentSession.programClips(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the outro take is 11s to 12.5s after the sync peak in the recording, which is 11.11s to 12.625s on the camera
+	if c := clips[1]; c.In != 900277 || c.Out != 900315 {
+		t.Errorf("Expected source timecodes corrected for drift, got %d to %d", c.In, c.Out)
+	}
+	if start, shift := currentSession.mediaStartFrame(); start != 899974 || shift != 0 {
+		t.Errorf("Expected the audio to start 1.01s before the start timecode, got %d moved on by %d", start, shift)
+	}
+}
+
+func TestExportCamera(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+	currentSession.Doc.Cameras = []CameraSync{{Name: "B", Offset: 3 * time.Second, Confidence: 1}}
+
+	// every format is timed relative to the exported camera
+	for _, e := range exporters {
+		var main, b bytes.Buffer
+		exportCamera = ""
+		if err := e.write(&main, &currentSession); err != nil {
+			t.Fatal(err)
+		}
+		exportCamera = "B"
+		if err := e.write(&b, &currentSession); err != nil {
+			t.Fatal(err)
+		}
+		if main.String() == b.String() {
+			t.Errorf("Exporting %s for camera B should change its timings", e.name)
+		}
+		if e.name == "edl" && !strings.Contains(b.String(), "001  AX       A     C        09:59:59:00 10:00:01:00 ") {
+			t.Errorf("Expected EDL source timecodes relative to camera B:\n%s", b.String())
+		}
+	}
+
+	exportCamera = "C"
+	e, _ := findExporter("edl")
+	if _, err := currentSession.export(e); err == nil {
+		t.Errorf("Expected exporting for a camera without a sync take to fail")
+	}
+	dir, _ := currentSession.getSessionDir()
+	if _, err := os.Stat(path.Join(dir, "session-C.edl")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be written for a camera without a sync take")
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type fcpxmlDocument struct {
+	XMLName   xml.Name        `xml:"fcpxml"`
+	Version   string          `xml:"version,attr"`
+	Resources fcpxmlResources `xml:"resources"`
+	Event     fcpxmlEvent     `xml:"library>event"`
+}
+
+type fcpxmlResources struct {
+	Format fcpxmlFormat `xml:"format"`
+	Asset  fcpxmlAsset  `xml:"asset"`
+}
+
+type fcpxmlFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxmlAsset struct {
+	ID            string `xml:"id,attr"`
+	Name          string `xml:"name,attr"`
+	Start         string `xml:"start,attr"`
+	Duration      string `xml:"duration,attr"`
+	HasAudio      int    `xml:"hasAudio,attr"`
+	AudioSources  int    `xml:"audioSources,attr"`
+	AudioChannels int    `xml:"audioChannels,attr"`
+	AudioRate     int    `xml:"audioRate,attr"`
+	MediaRep      struct {
+		Kind string `xml:"kind,attr"`
+		Src  string `xml:"src,attr"`
+	} `xml:"media-rep"`
+}
+
+type fcpxmlEvent struct {
+	Name    string        `xml:"name,attr"`
+	Project fcpxmlProject `xml:"project"`
+}
+
+type fcpxmlProject struct {
+	Name     string         `xml:"name,attr"`
+	Sequence fcpxmlSequence `xml:"sequence"`
+}
+
+type fcpxmlSequence struct {
+	Format      string            `xml:"format,attr"`
+	Duration    string            `xml:"duration,attr"`
+	TCStart     string            `xml:"tcStart,attr"`
+	TCFormat    string            `xml:"tcFormat,attr"`
+	AudioLayout string            `xml:"audioLayout,attr"`
+	AudioRate   string            `xml:"audioRate,attr"`
+	Clips       []fcpxmlAssetClip `xml:"spine>asset-clip"`
+}
+
+type fcpxmlAssetClip struct {
+	Ref       string          `xml:"ref,attr"`
+	Name      string          `xml:"name,attr"`
+	Offset    string          `xml:"offset,attr"`
+	Start     string          `xml:"start,attr"`
+	Duration  string          `xml:"duration,attr"`
+	AudioRole string          `xml:"audioRole,attr"`
+	Markers   []fcpxmlMarker  `xml:"marker"`
+	Keywords  []fcpxmlKeyword `xml:"keyword"`
+	Note      string          `xml:"note,omitempty"`
+}
+
+type fcpxmlMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+type fcpxmlKeyword struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+// Formats a number of frames as a rational number of seconds.
+func fcpxmlTime(frames int, rate frameRate) string {
+	if frames == 0 {
+		return "0s"
+	}
+	num, den := int64(frames)*rate.den, rate.num
+	a, b := num, den
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		a = -a
+	}
+	num, den = num/a, den/a
+	if den == 1 {
+		return fmt.Sprintf("%ds", num)
+	}
+	return fmt.Sprintf("%d/%ds", num, den)
+}
+
+// Writes a Final Cut Pro X project with the session audio as an asset, and the chosen take of every chunk laid out
+// back to back in script order. Headers are marked on their first clip, take marks, ratings and tags become keywords,
+// and take notes become clip notes.
+func writeFCPXML(w io.Writer, s *Session) error {
+	clips, err := s.programClips(false)
+	if err != nil {
+		return err
+	}
+	audioURL, err := s.audioURL()
+	if err != nil {
+		return err
+	}
+	rate := exportFrameRate()
+	tcFormat := "NDF"
+	if rate.dropFrame {
+		tcFormat = "DF"
+	}
+	tcStart, err := rate.parseTimecode(timelineStart)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("Session %d", s.Id)
+	mediaStart, _ := s.mediaStartFrame()
+
+	doc := fcpxmlDocument{
+		Version: "1.9",
+		Resources: fcpxmlResources{
+			Format: fcpxmlFormat{
+				ID:            "r1",
+				FrameDuration: fcpxmlTime(1, rate),
+				Width:         1920,
+				Height:        1080,
+			},
+			Asset: fcpxmlAsset{
+				ID:            "r2",
+				Name:          "audio",
+				Start:         fcpxmlTime(mediaStart, rate),
+				Duration:      fmt.Sprintf("%d/%ds", len(s.Audio), sampleRate),
+				HasAudio:      1,
+				AudioSources:  1,
+				AudioChannels: 1,
+				AudioRate:     sampleRate,
+			},
+		},
+		Event: fcpxmlEvent{
+			Name: name,
+			Project: fcpxmlProject{
+				Name: name,
+				Sequence: fcpxmlSequence{
+					Format:      "r1",
+					TCStart:     fcpxmlTime(tcStart, rate),
+					TCFormat:    tcFormat,
+					AudioLayout: "mono",
+					AudioRate:   "44.1k",
+				},
+			},
+		},
+	}
+	doc.Resources.Asset.MediaRep.Kind = "original-media"
+	doc.Resources.Asset.MediaRep.Src = audioURL
+
+	offset := tcStart
+	lastHeader := ""
+	for i, c := range clips {
+		// clips are timed by the source timecodes of the asset, which starts at the timecode of the session audio
+		start := c.In
+		duration := c.Out - c.In
+		if duration < 1 {
+			duration = 1
+		}
+		clip := fcpxmlAssetClip{
+			Ref:       "r2",
+			Name:      fmt.Sprintf("%s %d take %d", c.Header, c.HeaderChunk, c.TakeIndex),
+			Offset:    fcpxmlTime(offset, rate),
+			Start:     fcpxmlTime(start, rate),
+			Duration:  fcpxmlTime(duration, rate),
+			AudioRole: "dialogue",
+			Note:      c.Take.Note,
+		}
+		if i == 0 || c.Header != lastHeader {
+			clip.Markers = append(clip.Markers, fcpxmlMarker{
+				Start:    clip.Start,
+				Duration: fcpxmlTime(1, rate),
+				Value:    c.Header,
+			})
+		}
+		if keywords := takeKeywords(c.Take); len(keywords) > 0 {
+			clip.Keywords = append(clip.Keywords, fcpxmlKeyword{
+				Start:    clip.Start,
+				Duration: clip.Duration,
+				Value:    strings.Join(keywords, ", "),
+			})
+		}
+		doc.Event.Project.Sequence.Clips = append(doc.Event.Project.Sequence.Clips, clip)
+		lastHeader = c.Header
+		offset += duration
+	}
+	doc.Event.Project.Sequence.Duration = fcpxmlTime(offset-tcStart, rate)
+
+	_, err = io.WriteString(w, xml.Header+"<!DOCTYPE fcpxml>\n")
+	if err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	err = e.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
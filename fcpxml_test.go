@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteFCPXML(t *testing.T) {
+	useExportTestSession(t)
+	currentSession.Doc.GetChunk(2).Takes[1].Tags = []string{"warm"}
+	currentSession.Doc.GetChunk(2).Takes[1].Rating = 4
+	currentSession.Doc.GetChunk(2).Takes[1].Note = "breath at the end"
+
+	var b bytes.Buffer
+	if err := writeFCPXML(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header+"<!DOCTYPE fcpxml>") {
+		t.Errorf("Missing doctype: %s", b.String())
+	}
+	var doc fcpxmlDocument
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.Resources.Asset.MediaRep.Src, "file:///") || !strings.HasSuffix(doc.Resources.Asset.MediaRep.Src, "/sessions/7/audio.wav") {
+		t.Errorf("Incorrect media: %s", doc.Resources.Asset.MediaRep.Src)
+	}
+	if doc.Resources.Format.FrameDuration != "1/30s" || doc.Event.Project.Sequence.TCStart != "3600s" {
+		t.Errorf("Incorrect format: %+v %+v", doc.Resources.Format, doc.Event.Project.Sequence)
+	}
+
+	clips := doc.Event.Project.Sequence.Clips
+	if len(clips) != 2 {
+		t.Fatalf("Expected 2 clips, got %d", len(clips))
+	}
+	// the audio starts a second before the sync peak at 00:00:00:00, so its timecodes are moved on by a day
+	if doc.Resources.Asset.Start != "86399s" {
+		t.Errorf("Incorrect asset start: %s", doc.Resources.Asset.Start)
+	}
+	expected := []fcpxmlAssetClip{
+		{Offset: "3600s", Start: "86401s", Duration: "2s"},
+		{Offset: "3602s", Start: "86411s", Duration: "3/2s"},
+	}
+	for i, c := range clips {
+		if c.Offset != expected[i].Offset || c.Start != expected[i].Start || c.Duration != expected[i].Duration {
+			t.Errorf("Clip %d: expected %+v, got %+v", i, expected[i], c)
+		}
+		if len(c.Markers) != 1 {
+			t.Errorf("Clip %d: expected a header marker, got %v", i, c.Markers)
+		}
+	}
+	if clips[0].Markers[0].Value != "Intro" || clips[1].Markers[0].Value != "Outro" {
+		t.Errorf("Incorrect header markers: %v %v", clips[0].Markers, clips[1].Markers)
+	}
+	if len(clips[1].Keywords) != 1 || clips[1].Keywords[0].Value != "bad, circled, ★★★★, warm" {
+		t.Errorf("Incorrect keywords: %v", clips[1].Keywords)
+	}
+	if clips[0].Note != "" || clips[1].Note != "breath at the end" {
+		t.Errorf("Incorrect notes: %q %q", clips[0].Note, clips[1].Note)
+	}
+	if doc.Event.Project.Sequence.Duration != "7/2s" {
+		t.Errorf("Incorrect sequence duration: %s", doc.Event.Project.Sequence.Duration)
+	}
+}
+
+func TestWriteFCPXMLStartTimecode(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+
+	var b bytes.Buffer
+	if err := writeFCPXML(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	var doc fcpxmlDocument
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	// the audio starts a second before the start timecode, and clips are cut from it by timecode
+	if doc.Resources.Asset.Start != "35999s" {
+		t.Errorf("Incorrect asset start: %s", doc.Resources.Asset.Start)
+	}
+	clips := doc.Event.Project.Sequence.Clips
+	if len(clips) != 2 || clips[0].Start != "36001s" || clips[1].Start != "36011s" {
+		t.Errorf("Incorrect clip starts: %+v", clips)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// The state of everything in the document that take operations can change.
+type docSnapshot struct {
+	desc           string
+	takes          [][]Take
+	syncTakes      []Take
+	syncOffset     time.Duration
+	syncDrift      float64
+	syncConfidence float64
+	syncManual     bool
+	cameras        []CameraSync
+	syncCandidates []time.Duration
+	selectedChunk  uint
+	selectedTake   int
+	viewingSync    bool
+}
+
+// Maximum number of operations that can be undone.
+const maxHistory = 200
+
+var undoStack []docSnapshot
+var redoStack []docSnapshot
+
+// Whether the most recent history step was recorded by recordHistoryMerged.
+var lastHistoryMerged bool
+
+func copyTakes(takes []Take) []Take {
+	if takes == nil {
+		return nil
+	}
+	c := make([]Take, len(takes))
+	copy(c, takes)
+	for i := range c {
+		c[i].Tags = append([]string(nil), takes[i].Tags...)
+	}
+	return c
+}
+
+func snapshotDocument(desc string, doc *Document) docSnapshot {
+	s := docSnapshot{
+		desc:           desc,
+		syncTakes:      copyTakes(doc.syncTakes),
+		syncOffset:     doc.SyncOffset,
+		syncDrift:      doc.SyncDrift,
+		syncConfidence: doc.SyncConfidence,
+		syncManual:     doc.SyncManual,
+		cameras:        append([]CameraSync(nil), doc.Cameras...),
+		syncCandidates: append([]time.Duration(nil), doc.syncCandidates...),
+		selectedChunk:  selectedChunk,
+		selectedTake:   selectedTake,
+		viewingSync:    viewingSyncTakes,
+	}
+	for i := 0; i < doc.CountChunks(); i++ {
+		s.takes = append(s.takes, copyTakes(doc.GetChunk(i).Takes))
+	}
+	return s
+}
+
+func (s *docSnapshot) restore(doc *Document) {
+	for i := 0; i < doc.CountChunks() && i < len(s.takes); i++ {
+		doc.GetChunk(i).Takes = copyTakes(s.takes[i])
+	}
+	doc.syncTakes = copyTakes(s.syncTakes)
+	doc.SyncOffset = s.syncOffset
+	doc.SyncDrift = s.syncDrift
+	doc.SyncConfidence = s.syncConfidence
+	doc.SyncManual = s.syncManual
+	doc.Cameras = append([]CameraSync(nil), s.cameras...)
+	doc.syncCandidates = append([]time.Duration(nil), s.syncCandidates...)
+	selectedChunk = s.selectedChunk
+	selectedTake = s.selectedTake
+	viewingSyncTakes = s.viewingSync
+}
+
+// Records the state of the document before a take operation is performed, so that it can be undone.
+func recordHistory(desc string) {
+	pushHistory(snapshotDocument(desc, &currentSession.Doc))
+}
+
+// Records a snapshot taken before an operation that turned out to change the document.
+func pushHistory(s docSnapshot) {
+	lastHistoryMerged = false
+	undoStack = append(undoStack, s)
+	if len(undoStack) > maxHistory {
+		undoStack = undoStack[1:]
+	}
+	redoStack = nil
+}
+
+// Like recordHistory, but repeated operations on the same take are merged into a single step,
+// so that nudging a boundary many times can be undone at once.
+func recordHistoryMerged(desc string) {
+	if len(undoStack) > 0 && len(redoStack) == 0 {
+		last := undoStack[len(undoStack)-1]
+		if last.desc == desc && last.selectedChunk == selectedChunk && last.selectedTake == selectedTake && lastHistoryMerged {
+			return
+		}
+	}
+	recordHistory(desc)
+	lastHistoryMerged = true
+}
+
+// Reverts the most recent take operation. Returns false if there is nothing to undo.
+func undoHistory() bool {
+	if len(undoStack) == 0 {
+		return false
+	}
+	lastHistoryMerged = false
+	s := undoStack[len(undoStack)-1]
+	undoStack = undoStack[:len(undoStack)-1]
+	redoStack = append(redoStack, snapshotDocument(s.desc, &currentSession.Doc))
+	s.restore(&currentSession.Doc)
+	log.Printf("undo: %s", s.desc)
+	return true
+}
+
+// Performs the most recently undone take operation again. Returns false if there is nothing to redo.
+func redoHistory() bool {
+	if len(redoStack) == 0 {
+		return false
+	}
+	lastHistoryMerged = false
+	s := redoStack[len(redoStack)-1]
+	redoStack = redoStack[:len(redoStack)-1]
+	undoStack = append(undoStack, snapshotDocument(s.desc, &currentSession.Doc))
+	s.restore(&currentSession.Doc)
+	log.Printf("redo: %s", s.desc)
+	return true
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"image"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mum4k/termdash/mouse"
+	"github.com/mum4k/termdash/terminal/terminalapi"
+)
+
+func TestUndoRedo(t *testing.T) {
+	currentSession = Session{
+		Doc: parseDoc("# Test\nchunk 1\n\nchunk 2"),
+	}
+	undoStack, redoStack = nil, nil
+	selectedChunk, selectedTake = 0, -1
+
+	recordHistory("create take")
+	chunk := currentSession.Doc.GetChunk(0)
+	chunk.Takes = append(chunk.Takes, Take{TimeSpan: TimeSpan{Start: time.Second, End: 2 * time.Second}, Tags: []string{"a"}})
+	selectedTake = 0
+
+	recordHistory("mark take")
+	chunk.Takes[0].Mark = Bad
+	chunk.Takes[0].Tags[0] = "b"
+
+	if !undoHistory() {
+		t.Fatal("Expected undo to succeed")
+	}
+	chunk = currentSession.Doc.GetChunk(0)
+	if chunk.Takes[0].Mark != Unmarked || chunk.Takes[0].Tags[0] != "a" {
+		t.Errorf("Expected mark to be undone, got %v %v", chunk.Takes[0].Mark, chunk.Takes[0].Tags)
+	}
+
+	if !undoHistory() {
+		t.Fatal("Expected undo to succeed")
+	}
+	if len(currentSession.Doc.GetChunk(0).Takes) != 0 || selectedTake != -1 {
+		t.Errorf("Expected take creation to be undone")
+	}
+	if undoHistory() {
+		t.Errorf("Expected nothing left to undo")
+	}
+
+	redoHistory()
+	redoHistory()
+	chunk = currentSession.Doc.GetChunk(0)
+	if len(chunk.Takes) != 1 || chunk.Takes[0].Mark != Bad || chunk.Takes[0].Tags[0] != "b" {
+		t.Errorf("Expected take operations to be redone, got %v", chunk.Takes)
+	}
+	if redoHistory() {
+		t.Errorf("Expected nothing left to redo")
+	}
+
+	undoHistory()
+	recordHistory("new operation")
+	if redoHistory() {
+		t.Errorf("Expected redo history to be cleared by a new operation")
+	}
+}
+
+func TestDragTakeBoundaryHistory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	currentSession = Session{
+		Doc:   parseDoc("# Test\nchunk 1"),
+		Audio: make([]int32, sampleRate*10),
+	}
+	currentSession.Doc.GetChunk(0).Takes = []Take{{TimeSpan: TimeSpan{Start: 2 * time.Second, End: 4 * time.Second}}}
+	undoStack, redoStack = nil, nil
+	selectedChunk, selectedTake, viewingSyncTakes = 0, 0, false
+	w := &AudioDisplayWidget{area: image.Rect(0, 0, 100, 10), window: TimeSpan{End: 10 * time.Second}}
+	press := func(x int, b mouse.Button) {
+		if err := w.Mouse(&terminalapi.Mouse{Position: image.Point{X: x, Y: 5}, Button: b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// clicking a boundary without moving it
+	press(20, mouse.ButtonLeft)
+	press(20, mouse.ButtonRelease)
+	if len(undoStack) != 0 {
+		t.Errorf("Expected no history for a click that moves nothing, got %d steps", len(undoStack))
+	}
+
+	press(20, mouse.ButtonLeft)
+	press(30, mouse.ButtonLeft)
+	press(30, mouse.ButtonRelease)
+	if len(undoStack) != 1 || currentSession.Doc.GetChunk(0).Takes[0].Start != 3*time.Second {
+		t.Fatalf("Expected one history step for the drag, got %d, take %v", len(undoStack), currentSession.Doc.GetChunk(0).Takes[0])
+	}
+	undoHistory()
+	if currentSession.Doc.GetChunk(0).Takes[0].Start != 2*time.Second {
+		t.Errorf("Expected the drag to be undone, got %v", currentSession.Doc.GetChunk(0).Takes[0])
+	}
+}
@@ -1,31 +1,66 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/mum4k/termdash/keyboard"
 )
 
+// Whether the arrow keys move through the takes instead of the chunks.
+var takeListFocused bool = false
+
 type keybind struct {
 	key      keyboard.Key
 	desc     string
 	callback func()
+	// Hidden keybinds are not listed in the controls, usually because a similar keybind already describes them.
+	hidden bool
 }
 
 func getAvailableKeybinds() []keybind {
 	var keys []keybind
 	if !isRecordingTake {
+		if takeListFocused {
+			keys = append(keys, []keybind{
+				{
+					key:      keyboard.KeyArrowDown,
+					desc:     "Next Take",
+					callback: keybindNextTake,
+				},
+				{
+					key:      keyboard.KeyArrowUp,
+					desc:     "Previous Take",
+					callback: keybindPreviousTake,
+				},
+				{
+					key:      keyboard.KeyTab,
+					desc:     "Focus Chunks",
+					callback: func() { takeListFocused = false },
+				},
+			}...)
+		} else {
+			keys = append(keys, []keybind{
+				{
+					key:      keyboard.KeyArrowDown,
+					desc:     "Next Chunk",
+					callback: keybindNextChunk,
+				},
+				{
+					key:      keyboard.KeyArrowUp,
+					desc:     "Previous Chunk",
+					callback: keybindPreviousChunk,
+				},
+				{
+					key:      keyboard.KeyTab,
+					desc:     "Focus Takes",
+					callback: func() { takeListFocused = true },
+				},
+			}...)
+		}
 		keys = append(keys, []keybind{
-			{
-				key:      keyboard.KeyArrowDown,
-				desc:     "Next Chunk",
-				callback: keybindNextChunk,
-			},
-			{
-				key:      keyboard.KeyArrowUp,
-				desc:     "Previous Chunk",
-				callback: keybindPreviousChunk,
-			},
 			{
 				key:      ' ',
 				desc:     "Start Take",
@@ -36,14 +71,62 @@ func getAvailableKeybinds() []keybind {
 				desc:     "Start Sync Take",
 				callback: func() { startTake(true) },
 			},
+			{
+				key:      'S',
+				desc:     "Slate",
+				callback: func() { go playSlate() },
+			},
+			{
+				key:      'E',
+				desc:     "Export",
+				callback: func() { go exportAll() },
+			},
 			{
 				key:      'r',
 				desc:     "End Session",
 				callback: keybindEndSession,
 			},
+			{
+				key:      'P',
+				desc:     "Play Program",
+				callback: func() { go playProgram() },
+			},
 		}...)
 
-		if len(currentSession.Doc.headers) > 0 && len(currentSession.Doc.GetChunk(int(selectedChunk)).Takes) > 0 {
+		if pendingTransfer != nil {
+			keys = append(keys, []keybind{
+				{
+					key:      keyboard.KeyEnter,
+					desc:     pendingTransfer.desc() + " Here",
+					callback: keybindCompleteTransfer,
+				},
+				{
+					key:      'x',
+					desc:     "Cancel " + pendingTransfer.desc(),
+					callback: func() { pendingTransfer = nil },
+				},
+			}...)
+		}
+
+		if syncReferencePath != "" && !isSyncingToReference {
+			keys = append(keys, keybind{
+				key:      keyboard.KeyCtrlR,
+				desc:     "Sync to Reference",
+				callback: func() { go syncRecordingToReference() },
+			})
+		}
+
+		syncDesc := "Show Sync Takes"
+		if viewingSyncTakes {
+			syncDesc = "Show Chunk Takes"
+		}
+		keys = append(keys, keybind{
+			key:      'y',
+			desc:     syncDesc,
+			callback: keybindToggleSyncTakes,
+		})
+
+		if len(currentSession.Doc.headers) > 0 && len(*selectedTakes()) > 0 {
 			keys = append(keys, []keybind{
 				{
 					key:      'g',
@@ -60,7 +143,194 @@ func getAvailableKeybinds() []keybind {
 					desc:     "Play Selected Take",
 					callback: keybindPlayTake,
 				},
+				{
+					key:      'o',
+					desc:     "Circle Take",
+					callback: keybindCircleTake,
+				},
+				{
+					key:      'n',
+					desc:     "Note",
+					callback: keybindEditNote,
+				},
+				{
+					key:      '#',
+					desc:     "Tags",
+					callback: keybindEditTags,
+				},
+				{
+					key:      '0',
+					desc:     "Rate (0-5)",
+					callback: func() { keybindRate(0) },
+				},
 			}...)
+			for r := uint8(1); r <= 5; r++ {
+				rating := r
+				keys = append(keys, keybind{
+					key:      keyboard.Key('0' + rating),
+					callback: func() { keybindRate(rating) },
+					hidden:   true,
+				})
+			}
+			keys = append(keys, []keybind{
+				{
+					key:      'd',
+					desc:     "Delete Take",
+					callback: keybindDeleteTake,
+				},
+				{
+					key:      ',',
+					desc:     "Nudge In (,/.)",
+					callback: func() { keybindNudgeTake(false, -1) },
+				},
+				{
+					key:      '.',
+					callback: func() { keybindNudgeTake(false, 1) },
+					hidden:   true,
+				},
+				{
+					key:      '<',
+					desc:     "Nudge Out (</>)",
+					callback: func() { keybindNudgeTake(true, -1) },
+				},
+				{
+					key:      '>',
+					callback: func() { keybindNudgeTake(true, 1) },
+					hidden:   true,
+				},
+				{
+					key:      'm',
+					desc:     "Nudge Step: " + nudgeStep.String(),
+					callback: func() { nudgeStep = nudgeStep.next() },
+				},
+				{
+					key:      'z',
+					desc:     "Snap to Zero Crossing",
+					callback: keybindSnapTake,
+				},
+				{
+					key:      'v',
+					desc:     "Move Take",
+					callback: func() { beginTransfer(false) },
+				},
+				{
+					key:      'V',
+					desc:     "Copy Take",
+					callback: func() { beginTransfer(true) },
+				},
+				{
+					key:      'k',
+					desc:     "Add Split Point",
+					callback: keybindAddSplitPoint,
+				},
+				{
+					key:      'K',
+					desc:     "Suggest Split Points",
+					callback: keybindSuggestSplitPoints,
+				},
+				{
+					key:      'c',
+					desc:     "Compare Takes",
+					callback: func() { go compareTakes(false) },
+				},
+				{
+					key:      'C',
+					desc:     "Compare Good Takes",
+					callback: func() { go compareTakes(true) },
+				},
+			}...)
+		}
+
+		if isPlaying || isComparing || isPlayingProgram {
+			keys = append(keys,
+				keybind{
+					key:      'q',
+					desc:     "Stop Playback",
+					callback: func() { stopPlaybackRequested = true },
+				},
+			)
+		}
+
+		if len(splitPoints) > 0 {
+			keys = append(keys, []keybind{
+				{
+					key:      keyboard.KeyEnter,
+					desc:     fmt.Sprintf("Split Take (%d parts)", len(splitPoints)+1),
+					callback: keybindApplySplit,
+				},
+				{
+					key:      'x',
+					desc:     "Clear Split Points",
+					callback: func() { splitPoints = nil },
+				},
+			}...)
+		}
+
+		// camera times are only used to correct the drift of the main camera
+		if take := getSelectedTake(); viewingSyncTakes && take != nil && take.Camera == "" && selectedTake > currentSession.Doc.firstSyncTake("") {
+			keys = append(keys,
+				keybind{
+					key:      'a',
+					desc:     "Set Camera Time",
+					callback: keybindSetCameraTime,
+				},
+			)
+		}
+
+		if viewingSyncTakes && selectedTake >= 0 && selectedTake < len(currentSession.Doc.syncTakes) {
+			keys = append(keys,
+				keybind{
+					key:      'A',
+					desc:     "Set Camera Name",
+					callback: keybindSetCameraName,
+				},
+			)
+		}
+
+		if viewingSyncTakes {
+			keys = append(keys, []keybind{
+				{
+					key:      'j',
+					desc:     "Set Sync Offset Here",
+					callback: keybindPlaceSyncOffset,
+				},
+				{
+					key:      '[',
+					desc:     fmt.Sprintf("Nudge Sync Offset ([/] by %s)", nudgeStep),
+					callback: func() { keybindNudgeSyncOffset(-1) },
+				},
+				{
+					key:      ']',
+					callback: func() { keybindNudgeSyncOffset(1) },
+					hidden:   true,
+				},
+			}...)
+			if len(currentSession.Doc.syncTakes) > 0 {
+				keys = append(keys, keybind{
+					key:      'J',
+					desc:     "Detect Sync Offset",
+					callback: keybindDetectSyncOffset,
+				})
+			}
+		}
+
+		if len(undoStack) > 0 {
+			keys = append(keys,
+				keybind{
+					key:      keyboard.KeyCtrlZ,
+					desc:     "Undo " + undoStack[len(undoStack)-1].desc,
+					callback: keybindUndo,
+				},
+			)
+		}
+		if len(redoStack) > 0 {
+			keys = append(keys,
+				keybind{
+					key:      keyboard.KeyCtrlY,
+					desc:     "Redo " + redoStack[len(redoStack)-1].desc,
+					callback: keybindRedo,
+				},
+			)
 		}
 
 		if ui.audio.selectionActive {
@@ -92,6 +362,16 @@ func getAvailableKeybinds() []keybind {
 		}...)
 	}
 	keys = append(keys, []keybind{
+		{
+			key:      '-',
+			desc:     fmt.Sprintf("Slower (%.2fx)", playbackSpeed),
+			callback: func() { changePlaybackSpeed(-0.25) },
+		},
+		{
+			key:      '=',
+			desc:     "Faster",
+			callback: func() { changePlaybackSpeed(0.25) },
+		},
 		{
 			key:      'f',
 			desc:     "Toggle Stick Viewport To End",
@@ -113,6 +393,8 @@ func keybindPreviousChunk() {
 	if selectedChunk > 0 {
 		selectedChunk -= 1
 	}
+	splitPoints = nil
+	viewingSyncTakes = false
 	chunk := currentSession.Doc.GetChunk(int(selectedChunk))
 	selectedTake = len(chunk.Takes) - 1
 }
@@ -124,26 +406,63 @@ func keybindNextChunk() {
 	if selectedChunk < uint(currentSession.Doc.CountChunks()-1) {
 		selectedChunk += 1
 	}
+	splitPoints = nil
+	viewingSyncTakes = false
 	chunk := currentSession.Doc.GetChunk(int(selectedChunk))
 	selectedTake = len(chunk.Takes) - 1
 }
 
-func keybindMarkGood() {
-	currentSession.Doc.GetChunk(int(selectedChunk)).Takes[selectedTake].Mark = Good
+// Selects the take, and shows it in the waveform.
+func selectTake(index int) {
+	takes := *selectedTakes()
+	if index < 0 || index >= len(takes) {
+		return
+	}
+	selectedTake = index
+	splitPoints = nil
+	ui.audio.CenterOn(takes[index].TimeSpan)
+}
+
+func keybindPreviousTake() {
 	if isRecordingTake {
-		endTake()
+		return
+	}
+	selectTake(selectedTake - 1)
+}
+
+func keybindNextTake() {
+	if isRecordingTake {
+		return
 	}
+	selectTake(selectedTake + 1)
+}
+
+func keybindMarkGood() {
+	markSelectedTake(Good)
 }
 
 func keybindMarkBad() {
-	currentSession.Doc.GetChunk(int(selectedChunk)).Takes[selectedTake].Mark = Bad
+	markSelectedTake(Bad)
+}
+
+func markSelectedTake(mark TakeMark) {
+	// Sync takes are always marked as sync.
+	if take := getSelectedTake(); take != nil && take.Mark != Sync {
+		if !isRecordingTake {
+			recordHistory("Mark " + mark.String())
+		}
+		take.Mark = mark
+	}
 	if isRecordingTake {
 		endTake()
+	} else {
+		currentSession.FullSave()
 	}
 }
 
 func keybindCreateTakeFromSelection() {
 	if ui.audio.selectionActive {
+		recordHistory("New Take")
 		chunk := currentSession.Doc.GetChunk(int(selectedChunk))
 		take := Take{}
 		take.Start = ui.audio.selected.Start
@@ -151,6 +470,7 @@ func keybindCreateTakeFromSelection() {
 		chunk.Takes = append(chunk.Takes, take)
 		selectedTake = len(chunk.Takes) - 1
 		ui.audio.Deselect()
+		currentSession.FullSave()
 	}
 }
 
@@ -162,6 +482,300 @@ func keybindEndSession() {
 }
 
 func keybindPlayTake() {
-	take := currentSession.Doc.GetChunk(int(selectedChunk)).Takes[selectedTake]
-	go playbackTake(take)
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	go playbackTake(*take)
+}
+
+// Returns the list of takes that selectedTake refers to: the sync takes if they are being
+// shown or recorded, otherwise the takes of the selected chunk.
+func selectedTakes() *[]Take {
+	if viewingSyncTakes || isRecordingSyncTake {
+		return &currentSession.Doc.syncTakes
+	}
+	return &currentSession.Doc.GetChunk(int(selectedChunk)).Takes
+}
+
+func getSelectedTake() *Take {
+	takes := *selectedTakes()
+	if selectedTake < 0 || selectedTake >= len(takes) {
+		return nil
+	}
+	return &takes[selectedTake]
+}
+
+func keybindRate(rating uint8) {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	recordHistory("Rate")
+	take.Rating = rating
+	currentSession.FullSave()
+}
+
+func keybindEditNote() {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	openPrompt("Note", take.Note, func(note string) {
+		take := getSelectedTake()
+		if take == nil {
+			return
+		}
+		recordHistory("Note")
+		take.Note = strings.TrimSpace(note)
+		currentSession.FullSave()
+	})
+}
+
+func keybindEditTags() {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	openPrompt("Tags (comma separated)", strings.Join(take.Tags, ", "), func(tags string) {
+		take := getSelectedTake()
+		if take == nil {
+			return
+		}
+		recordHistory("Tags")
+		take.Tags = parseTags(tags)
+		currentSession.FullSave()
+	})
+}
+
+func keybindUndo() {
+	if undoHistory() {
+		currentSession.FullSave()
+	}
+}
+
+func keybindRedo() {
+	if redoHistory() {
+		currentSession.FullSave()
+	}
+}
+
+func keybindDeleteTake() {
+	takes := selectedTakes()
+	if selectedTake < 0 || selectedTake >= len(*takes) {
+		return
+	}
+	recordHistory("Delete Take")
+	syncBefore, hadSync := currentSession.Doc.firstSyncSpan()
+	*takes = deleteTake(*takes, selectedTake)
+	selectedTake = clamp(selectedTake, 0, len(*takes)-1)
+	if viewingSyncTakes {
+		var err error
+		if syncAfter, hasSync := currentSession.Doc.firstSyncSpan(); hasSync != hadSync || syncAfter != syncBefore {
+			err = currentSession.updateSyncOffset()
+		}
+		if cameraErr := currentSession.updateCameraSyncs(); err == nil {
+			err = cameraErr
+		}
+		if err != nil {
+			setStatus("%s", err)
+		}
+	}
+	currentSession.FullSave()
+}
+
+func keybindNudgeTake(end bool, steps int) {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	if end {
+		recordHistoryMerged("Nudge Out")
+	} else {
+		recordHistoryMerged("Nudge In")
+	}
+	nudgeTake(take, end, steps, nudgeStep)
+	if err := resyncEditedTake(take); err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
+}
+
+func keybindSnapTake() {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	recordHistory("Snap to Zero Crossing")
+	snapTakeToZeroCrossings(take)
+	if err := resyncEditedTake(take); err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
+}
+
+func keybindAddSplitPoint() {
+	take := getSelectedTake()
+	cursor, ok := ui.audio.Cursor()
+	if take == nil || !ok {
+		return
+	}
+	if cursor <= take.Start || cursor >= take.End {
+		setStatus("Split points must be inside the selected take")
+		return
+	}
+	splitPoints = append(splitPoints, cursor)
+	// Enter and x would complete or cancel the transfer instead of the split
+	pendingTransfer = nil
+}
+
+func keybindSuggestSplitPoints() {
+	take := getSelectedTake()
+	if take == nil {
+		return
+	}
+	following := currentSession.Doc.CountChunks() - int(selectedChunk) - 1
+	splitPoints = nil
+	for _, p := range suggestSplitPoints(currentSession.ExtractAudio(take.TimeSpan), following) {
+		splitPoints = append(splitPoints, take.Start+samplesToDuration(sampleRate, p))
+	}
+	if len(splitPoints) == 0 {
+		setStatus("No pauses found to split the take at")
+		return
+	}
+	pendingTransfer = nil
+}
+
+func keybindApplySplit() {
+	err := applySplit()
+	if err != nil {
+		setStatus("Failed to split take: %s", err)
+		return
+	}
+	currentSession.FullSave()
+}
+
+func keybindToggleSyncTakes() {
+	viewingSyncTakes = !viewingSyncTakes
+	splitPoints = nil
+	selectedTake = len(*selectedTakes()) - 1
+}
+
+func keybindCompleteTransfer() {
+	err := completeTransfer()
+	if err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
+}
+
+func keybindCircleTake() {
+	if viewingSyncTakes {
+		return
+	}
+	chunk := currentSession.Doc.GetChunk(int(selectedChunk))
+	if selectedTake < 0 || selectedTake >= len(chunk.Takes) {
+		return
+	}
+	recordHistory("Circle Take")
+	chunk.ToggleCircle(selectedTake)
+	currentSession.FullSave()
+}
+
+func keybindSetCameraTime() {
+	take := getSelectedTake()
+	if take == nil || !viewingSyncTakes {
+		return
+	}
+	initial := ""
+	if take.CameraTime != 0 {
+		initial = Timestamp(&take.CameraTime)
+	}
+	openPrompt("Time of this sync peak on the camera, after the first sync peak", initial, func(input string) {
+		take := getSelectedTake()
+		if take == nil {
+			return
+		}
+		var cameraTime time.Duration
+		if strings.TrimSpace(input) != "" {
+			var err error
+			cameraTime, err = ParseTimestamp(input)
+			if err != nil {
+				setStatus("%s", err)
+				return
+			}
+		}
+		recordHistory("Set Camera Time")
+		take.CameraTime = cameraTime
+		err := currentSession.updateSyncDrift()
+		if err != nil {
+			setStatus("%s", err)
+		}
+		currentSession.FullSave()
+	})
+}
+
+func keybindSetCameraName() {
+	take := getSelectedTake()
+	if take == nil || !viewingSyncTakes {
+		return
+	}
+	openPrompt("Camera this sync take is for (empty for the main camera)", take.Camera, func(input string) {
+		take := getSelectedTake()
+		if take == nil {
+			return
+		}
+		recordHistory("Set Camera Name")
+		syncBefore, hadSync := currentSession.Doc.firstSyncSpan()
+		take.Camera = strings.TrimSpace(input)
+		var err error
+		if syncAfter, hasSync := currentSession.Doc.firstSyncSpan(); hasSync != hadSync || syncAfter != syncBefore {
+			err = currentSession.updateSyncOffset()
+		}
+		if cameraErr := currentSession.updateCameraSyncs(); err == nil {
+			err = cameraErr
+		}
+		if err != nil {
+			setStatus("%s", err)
+		}
+		currentSession.FullSave()
+	})
+}
+
+// Places the sync offset at the playhead while playing, otherwise at the waveform cursor.
+func keybindPlaceSyncOffset() {
+	var t time.Duration
+	if isPlaying {
+		t = samplesToDuration(sampleRate, playbackPosition)
+	} else if cursor, ok := ui.audio.Cursor(); ok {
+		t = cursor
+	} else {
+		setStatus("Click the waveform or play audio to place the sync offset")
+		return
+	}
+	recordHistory("Set Sync Offset")
+	err := currentSession.setSyncOffset(t)
+	if err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
+}
+
+func keybindNudgeSyncOffset(steps int) {
+	recordHistoryMerged("Nudge Sync Offset")
+	err := currentSession.setSyncOffset(nudgeTimestamp(currentSession.Doc.SyncOffset, steps, nudgeStep))
+	if err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
+}
+
+// Discards a manually placed sync offset and detects it from the first sync take again.
+func keybindDetectSyncOffset() {
+	recordHistory("Detect Sync Offset")
+	err := currentSession.updateSyncOffset()
+	if err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
 }
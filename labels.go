@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A take labelled for audio editors, timed by its position in the session audio so that it lines up with it. The text
+// starts with the source timecode of the take, like the clips of other exports.
+type takeLabel struct {
+	TimeSpan
+	Text string
+}
+
+// Describes the rating, tags and note of a take, to follow the rest of its label. Labels are a single line, so the
+// note is too.
+func takeLabelDetails(take Take) string {
+	var details string
+	if take.Rating > 0 {
+		details += " " + strings.Repeat("★", int(take.Rating))
+	}
+	if len(take.Tags) > 0 {
+		details += " (" + strings.Join(take.Tags, ", ") + ")"
+	}
+	if note := strings.Join(strings.Fields(take.Note), " "); note != "" {
+		details += ": " + note
+	}
+	return details
+}
+
+// Returns a label for every take, including sync takes, in the order they were recorded.
+func (s *Session) takeLabels() ([]takeLabel, error) {
+	if err := s.checkExportCamera(); err != nil {
+		return nil, err
+	}
+	rate := exportFrameRate()
+	var labels []takeLabel
+	add := func(take Take, text string) {
+		synced, _ := s.Doc.SyncedTimeFor(exportCamera, take.Start)
+		tc := rate.timecode(exportFrame(synced))
+		labels = append(labels, takeLabel{take.TimeSpan, "[" + tc + "] " + text + takeLabelDetails(take)})
+	}
+	for i, take := range s.Doc.syncTakes {
+		text := fmt.Sprintf("sync take %d", i)
+		if take.Camera != "" {
+			text += " cam " + take.Camera
+		}
+		add(take, text)
+	}
+	for _, header := range s.Doc.headers {
+		title := headerTitle(header.Text)
+		for c, ch := range header.Chunks {
+			for t, take := range ch.Takes {
+				text := fmt.Sprintf("%s %d take %d %s", title, c, t, take.Mark)
+				if take.Circled {
+					text += " circled"
+				}
+				add(take, text)
+			}
+		}
+	}
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].Start < labels[j].Start
+	})
+	return labels, nil
+}
+
+// Writes an Audacity label track with a region for every take.
+func writeAudacityLabels(w io.Writer, s *Session) error {
+	labels, err := s.takeLabels()
+	if err != nil {
+		return err
+	}
+	for _, l := range labels {
+		_, err := fmt.Fprintf(w, "%.6f\t%.6f\t%s\n", l.Start.Seconds(), l.End.Seconds(), l.Text)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Quotes a string for a Reaper project file, which has no escapes, so a quote character that the string doesn't
+// contain is used.
+func reaperQuote(s string) string {
+	for _, q := range []string{`"`, `'`, "`"} {
+		if !strings.Contains(s, q) {
+			return q + s + q
+		}
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `'`) + `"`
+}
+
+// Writes a Reaper project with the session audio on a track, and a region for every take. The project starts at the
+// timecode of the session audio, so that the ruler shows source timecodes.
+func writeReaperProject(w io.Writer, s *Session) error {
+	audioPath, err := s.audioPath()
+	if err != nil {
+		return err
+	}
+	labels, err := s.takeLabels()
+	if err != nil {
+		return err
+	}
+	mediaStart, _ := s.mediaStartFrame()
+	var b strings.Builder
+	fmt.Fprintf(&b, "<REAPER_PROJECT 0.1 \"6.0\" 0\n")
+	fmt.Fprintf(&b, "  SAMPLERATE %d 0 0\n", sampleRate)
+	fmt.Fprintf(&b, "  PROJOFFS %.6f 0 0\n", exportFrameRate().framesToDuration(mediaStart).Seconds())
+	for i, l := range labels {
+		// a region is a pair of markers with the same index
+		fmt.Fprintf(&b, "  MARKER %d %.6f %s 1\n", i+1, l.Start.Seconds(), reaperQuote(l.Text))
+		fmt.Fprintf(&b, "  MARKER %d %.6f \"\" 1\n", i+1, l.End.Seconds())
+	}
+	fmt.Fprintf(&b, "  <TRACK\n")
+	fmt.Fprintf(&b, "    NAME %s\n", reaperQuote(fmt.Sprintf("Session %d", s.Id)))
+	fmt.Fprintf(&b, "    <ITEM\n")
+	fmt.Fprintf(&b, "      POSITION 0\n")
+	fmt.Fprintf(&b, "      LENGTH %.6f\n", samplesToDuration(sampleRate, len(s.Audio)).Seconds())
+	fmt.Fprintf(&b, "      NAME audio.wav\n")
+	fmt.Fprintf(&b, "      <SOURCE WAVE\n")
+	fmt.Fprintf(&b, "        FILE %s\n", reaperQuote(audioPath))
+	fmt.Fprintf(&b, "      >\n")
+	fmt.Fprintf(&b, "    >\n")
+	fmt.Fprintf(&b, "  >\n")
+	fmt.Fprintf(&b, ">\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAudacityLabels(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 500 * time.Millisecond, End: 1500 * time.Millisecond}, Mark: Sync},
+	}
+	take := &currentSession.Doc.GetChunk(2).Takes[1]
+	take.Rating, take.Tags, take.Note = 3, []string{"warm", "slow"}, "pops on\tthe p"
+
+	var b bytes.Buffer
+	if err := writeAudacityLabels(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	// labels line up with the audio, and start with the timecode synced 1 second into it
+	expected := `0.500000	1.500000	[09:59:59:12] sync take 0
+2.000000	4.000000	[10:00:01:00] Intro 0 take 0 good
+5.000000	7.000000	[10:00:04:00] Intro 0 take 1 bad
+8.000000	9.000000	[10:00:07:00] Intro 1 take 0 unmarked
+10.000000	11.000000	[10:00:09:00] Outro 0 take 0 good
+12.000000	13.500000	[10:00:11:00] Outro 0 take 1 bad circled ★★★ (warm, slow): pops on the p
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, b.String())
+	}
+}
+
+func TestWriteReaperProject(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+
+	var b bytes.Buffer
+	if err := writeReaperProject(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	rpp := b.String()
+	for _, line := range []string{
+		`  PROJOFFS 35999.000000 0 0`,
+		`  MARKER 1 2.000000 "[10:00:01:00] Intro 0 take 0 good" 1`,
+		`  MARKER 1 4.000000 "" 1`,
+		`  MARKER 5 13.500000 "" 1`,
+		`      LENGTH 15.000000`,
+	} {
+		if !strings.Contains(rpp, line+"\n") {
+			t.Errorf("Missing %q in:\n%s", line, rpp)
+		}
+	}
+	if !strings.Contains(rpp, "/sessions/7/audio.wav\"\n") {
+		t.Errorf("The project should reference the session audio:\n%s", rpp)
+	}
+}
+
+func TestReaperQuote(t *testing.T) {
+	for s, expected := range map[string]string{
+		`plain`:      `"plain"`,
+		`say "hi"`:   `'say "hi"'`,
+		`"it's"`:     "`\"it's\"`",
+		"\"it's\" `": `"'it's' ` + "`\"",
+	} {
+		if q := reaperQuote(s); q != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, s, q)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-audio/wav"
+)
+
+// Whether the second input channel carries linear timecode, which is decoded while recording.
+var ltcInputChannel bool = false
+
+// Path to a wav file of linear timecode recorded alongside a finished session, if any.
+var ltcFilePath string
+
+var ltcStream chan []int32 = make(chan []int32, 10)
+
+// The sync word at the end of every LTC frame, in the order the bits are received.
+var ltcSyncWord = [16]uint8{0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 1}
+
+const (
+	ltcFrameBits = 80
+	// LTC bits are never longer than at 23.976 fps, with some room for wow and flutter.
+	maxLTCBitPeriod = sampleRate / (80 * 23) * 3 / 2
+	// Number of transitions used to find the bit period before decoding.
+	ltcWarmup = 16
+)
+
+// A decoded LTC frame.
+type ltcFrame struct {
+	// Sample at which the frame starts
+	Position                        int
+	Hours, Minutes, Seconds, Frames int
+	DropFrame                       bool
+}
+
+// Decodes biphase mark coded linear timecode from audio that is written to it in buffers.
+type ltcDecoder struct {
+	position int
+	high     bool
+	peak     float64
+
+	lastTransition int
+	// Number of transitions seen while finding the bit period
+	transitions int
+	bitPeriod   float64
+	// Whether the first half of a 1 bit has been seen
+	halfPending bool
+	// Sample at which the bit currently being received started
+	bitStart int
+
+	bits      [ltcFrameBits]uint8
+	bitStarts [ltcFrameBits]int
+	// Number of bits received since the signal was lost
+	received int
+}
+
+// Decodes the samples, which follow the previously written samples. Returns the frames that were completed.
+func (d *ltcDecoder) Write(samples []int32) []ltcFrame {
+	var frames []ltcFrame
+	for _, s := range samples {
+		v := math.Abs(float64(s))
+		d.peak = math.Max(v, d.peak*0.9995)
+		threshold := d.peak / 4
+		if (!d.high && float64(s) > threshold) || (d.high && float64(s) < -threshold) {
+			d.high = !d.high
+			if f, ok := d.transition(d.position); ok {
+				frames = append(frames, f)
+			}
+		}
+		d.position++
+	}
+	return frames
+}
+
+func (d *ltcDecoder) transition(p int) (ltcFrame, bool) {
+	interval := p - d.lastTransition
+	d.lastTransition = p
+	if interval > maxLTCBitPeriod {
+		// silence or a dropout
+		d.halfPending = false
+		d.received = 0
+		d.bitStart = p
+		return ltcFrame{}, false
+	}
+	if d.transitions < ltcWarmup {
+		d.transitions++
+		d.bitPeriod = math.Max(d.bitPeriod, float64(interval))
+		d.bitStart = p
+		return ltcFrame{}, false
+	}
+
+	if float64(interval) > d.bitPeriod*3/4 {
+		// A half bit without its other half can't be decoded, so it is dropped.
+		d.halfPending = false
+		d.bitPeriod = d.bitPeriod*0.9 + float64(p-d.bitStart)*0.1
+		return d.receiveBit(0, p)
+	}
+	if !d.halfPending {
+		d.halfPending = true
+		return ltcFrame{}, false
+	}
+	d.halfPending = false
+	d.bitPeriod = d.bitPeriod*0.9 + float64(p-d.bitStart)*0.1
+	return d.receiveBit(1, p)
+}
+
+// Adds the bit, which ended at the sample, and decodes a frame if it completed one.
+func (d *ltcDecoder) receiveBit(bit uint8, end int) (ltcFrame, bool) {
+	copy(d.bits[:], d.bits[1:])
+	copy(d.bitStarts[:], d.bitStarts[1:])
+	d.bits[ltcFrameBits-1] = bit
+	d.bitStarts[ltcFrameBits-1] = d.bitStart
+	d.bitStart = end
+	d.received++
+
+	if d.received < ltcFrameBits {
+		return ltcFrame{}, false
+	}
+	for i, b := range ltcSyncWord {
+		if d.bits[ltcFrameBits-len(ltcSyncWord)+i] != b {
+			return ltcFrame{}, false
+		}
+	}
+	field := func(start, length int) int {
+		v := 0
+		for i := 0; i < length; i++ {
+			v |= int(d.bits[start+i]) << i
+		}
+		return v
+	}
+	return ltcFrame{
+		Position:  d.bitStarts[0],
+		Frames:    field(0, 4) + 10*field(8, 2),
+		DropFrame: d.bits[10] == 1,
+		Seconds:   field(16, 4) + 10*field(24, 3),
+		Minutes:   field(32, 4) + 10*field(40, 3),
+		Hours:     field(48, 4) + 10*field(56, 2),
+	}, true
+}
+
+func decodeLTC(samples []int32) []ltcFrame {
+	var d ltcDecoder
+	return d.Write(samples)
+}
+
+// Reads the last channel of a wav file.
+func readLTCFile(path string) ([]int32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := wav.NewDecoder(f)
+	if !d.IsValidFile() {
+		return nil, errors.New("Not a valid wav file: " + path)
+	}
+	buf, err := d.FullPCMBuffer()
+	if err != nil {
+		return nil, err
+	}
+	if buf.Format.SampleRate != sampleRate {
+		return nil, errors.New("Timecode must be recorded at the same sample rate as the session")
+	}
+	channels := buf.Format.NumChannels
+	samples := make([]int32, len(buf.Data)/channels)
+	for i := range samples {
+		samples[i] = int32(buf.Data[i*channels+channels-1])
+	}
+	return samples, nil
+}
+
+// A run of consecutive LTC frames.
+type LTCSegment struct {
+	// Position in the recording of the first and last frame
+	Start, End time.Duration
+	// Frame count of the timecodes of the first and last frame
+	StartFrame, EndFrame int
+}
+
+// Timecode decoded from linear timecode recorded with the session. Every segment after the first starts at a
+// discontinuity in the timecode.
+type LTCTrack struct {
+	Rate     frameRate
+	Segments []LTCSegment
+	// Number of invalid frames in a row, so that they are only reported once
+	invalidFrames int
+}
+
+// Minimum number of frames to measure the frame rate of LTC with.
+const ltcRateFrames = 25
+
+// Builds the timecode track from decoded frames. The frame rate is measured from the frames, unless a timecode frame
+// rate was given. Returns nil if there are not enough frames.
+func buildLTCTrack(frames []ltcFrame) *LTCTrack {
+	if len(frames) == 0 || (timecodeRate == nil && len(frames) < ltcRateFrames) {
+		return nil
+	}
+	var rate frameRate
+	if timecodeRate != nil {
+		rate = *timecodeRate
+	} else {
+		rate = measureLTCRate(frames)
+	}
+	track := &LTCTrack{Rate: rate}
+	for _, f := range frames {
+		track.add(f)
+	}
+	return track
+}
+
+// Finds the supported frame rate closest to the rate the frames were received at.
+func measureLTCRate(frames []ltcFrame) frameRate {
+	var intervals []int
+	dropFrame := false
+	for i, f := range frames {
+		dropFrame = dropFrame || f.DropFrame
+		if i > 0 {
+			intervals = append(intervals, f.Position-frames[i-1].Position)
+		}
+	}
+	sort.Ints(intervals)
+	median := intervals[len(intervals)/2]
+	// average the intervals between consecutive frames, for precision
+	total, n := 0, 0
+	for _, i := range intervals {
+		if i < median*5/4 {
+			total += i
+			n++
+		}
+	}
+	fps := float64(sampleRate) * float64(n) / float64(total)
+
+	var best *frameRate
+	for i, r := range frameRates {
+		if r.dropFrame == dropFrame && (best == nil || math.Abs(r.fps()-fps) < math.Abs(best.fps()-fps)) {
+			best = &frameRates[i]
+		}
+	}
+	return *best
+}
+
+// Adds a frame that was decoded after the previously added frames.
+func (t *LTCTrack) add(f ltcFrame) {
+	count, err := t.Rate.frameCount(f.Hours, f.Minutes, f.Seconds, f.Frames)
+	if err != nil {
+		if t.invalidFrames == 0 {
+			log.Printf("Ignoring invalid LTC frames from %02d:%02d:%02d:%02d, the timecode may not be %s fps", f.Hours, f.Minutes, f.Seconds, f.Frames, t.Rate)
+		}
+		t.invalidFrames++
+		return
+	}
+	if t.invalidFrames > 0 {
+		log.Printf("Ignored %d invalid LTC frames", t.invalidFrames)
+		t.invalidFrames = 0
+	}
+	at := samplesToDuration(sampleRate, f.Position)
+	if len(t.Segments) > 0 {
+		last := &t.Segments[len(t.Segments)-1]
+		frameDuration := t.Rate.framesToDuration(1)
+		if count == last.EndFrame+1 && at-last.End < frameDuration*3/2 {
+			last.End = at
+			last.EndFrame = count
+			return
+		}
+	}
+	t.Segments = append(t.Segments, LTCSegment{Start: at, End: at, StartFrame: count, EndFrame: count})
+}
+
+// Returns the frame count of the timecode at the position in the recording. Positions between the decoded frames of
+// a segment are interpolated, so that the drift between the audio clock and the timecode is corrected.
+func (t *LTCTrack) FrameAt(at time.Duration) (int, bool) {
+	if t == nil || len(t.Segments) == 0 {
+		return 0, false
+	}
+	seg := t.Segments[0]
+	for _, s := range t.Segments {
+		if s.Start <= at {
+			seg = s
+		}
+	}
+	if at >= seg.Start && at <= seg.End && seg.End > seg.Start {
+		return seg.StartFrame + int(int64(at-seg.Start)*int64(seg.EndFrame-seg.StartFrame)/int64(seg.End-seg.Start)), true
+	}
+	if at < seg.Start {
+		return seg.StartFrame + t.Rate.durationToFrames(at-seg.Start), true
+	}
+	return seg.EndFrame + t.Rate.durationToFrames(at-seg.End), true
+}
+
+// Returns the timecode at the position in the recording, or an empty string if there is no timecode.
+func (t *LTCTrack) TimecodeAt(at time.Duration) string {
+	frame, ok := t.FrameAt(at)
+	if !ok {
+		return ""
+	}
+	return t.Rate.timecode(frame)
+}
+
+// Decodes the timecode being recorded on the second input channel.
+func ltcProcessor() {
+	log.Print("LTC decoding started")
+	var d ltcDecoder
+	var frames []ltcFrame
+	for buffer := range ltcStream {
+		decoded := d.Write(buffer)
+		if currentSession.Doc.LTC != nil {
+			for _, f := range decoded {
+				currentSession.Doc.LTC.add(f)
+			}
+			continue
+		}
+		frames = append(frames, decoded...)
+		currentSession.Doc.LTC = buildLTCTrack(frames)
+		if currentSession.Doc.LTC != nil {
+			setStatus("Decoding %s fps timecode, starting at %s", currentSession.Doc.LTC.Rate, currentSession.Doc.LTC.TimecodeAt(0))
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Encodes LTC frames with the frame counts as biphase mark coded audio.
+func encodeLTC(rate frameRate, frames []int, amplitude float64) []int32 {
+	var bits []uint8
+	for _, frame := range frames {
+		tc := splitTimecode(rate.timecode(frame))
+		h, m, s, f := tc[0], tc[1], tc[2], tc[3]
+		var b [80]uint8
+		set := func(start, length, v int) {
+			for i := 0; i < length; i++ {
+				b[start+i] = uint8(v >> i & 1)
+			}
+		}
+		set(0, 4, f%10)
+		set(8, 2, f/10)
+		if rate.dropFrame {
+			b[10] = 1
+		}
+		set(16, 4, s%10)
+		set(24, 3, s/10)
+		set(32, 4, m%10)
+		set(40, 3, m/10)
+		set(48, 4, h%10)
+		set(56, 2, h/10)
+		copy(b[64:], ltcSyncWord[:])
+		bits = append(bits, b[:]...)
+	}
+
+	bitPeriod := float64(sampleRate) / (80 * rate.fps())
+	samples := make([]int32, int(float64(len(bits))*bitPeriod)+1)
+	level := amplitude
+	next := 0.0
+	for i, bit := range bits {
+		start := float64(i) * bitPeriod
+		for j := int(math.Ceil(next)); float64(j) < start && j < len(samples); j++ {
+			samples[j] = int32(level)
+		}
+		next = start
+		level = -level
+		if bit == 1 {
+			mid := start + bitPeriod/2
+			for j := int(math.Ceil(next)); float64(j) < mid; j++ {
+				samples[j] = int32(level)
+			}
+			next = mid
+			level = -level
+		}
+	}
+	for j := int(math.Ceil(next)); j < len(samples); j++ {
+		samples[j] = int32(level)
+	}
+	return samples
+}
+
+func consecutiveFrames(start, count int) []int {
+	var frames []int
+	for i := 0; i < count; i++ {
+		frames = append(frames, start+i)
+	}
+	return frames
+}
+
+func splitTimecode(tc string) []int {
+	var parts []int
+	v := 0
+	for _, c := range tc {
+		if c >= '0' && c <= '9' {
+			v = v*10 + int(c-'0')
+		} else {
+			parts = append(parts, v)
+			v = 0
+		}
+	}
+	return append(parts, v)
+}
+
+func TestDecodeLTC(t *testing.T) {
+	for _, name := range []string{"25", "29.97df", "23.976", "30"} {
+		rate, _ := parseFrameRate(name)
+		start, _ := rate.parseTimecode("09:59:58:00")
+		samples := append(make([]int32, sampleRate/10), encodeLTC(rate, consecutiveFrames(start, 120), math.MaxInt32/4)...)
+		frames := decodeLTC(samples)
+		if len(frames) < 118 {
+			t.Errorf("%s: expected 120 frames, got %d", name, len(frames))
+			continue
+		}
+		track := buildLTCTrack(frames)
+		if track.Rate != rate {
+			t.Errorf("%s: expected rate to be measured as %s, got %s", name, rate, track.Rate)
+		}
+		if len(track.Segments) != 1 {
+			t.Errorf("%s: expected no discontinuities, got %v", name, track.Segments)
+		}
+		// the start of the third second of timecode
+		expected := start + 2*rate.timebase()
+		at := sampleRate/10 + int(float64(2*rate.timebase())*float64(sampleRate)/rate.fps())
+		if tc := track.TimecodeAt(samplesToDuration(sampleRate, at+10)); tc != rate.timecode(expected) {
+			t.Errorf("%s: expected %s, got %s", name, rate.timecode(expected), tc)
+		}
+	}
+}
+
+func TestLTCDiscontinuity(t *testing.T) {
+	rate, _ := parseFrameRate("25")
+	samples := encodeLTC(rate, append(consecutiveFrames(1000, 50), consecutiveFrames(90000, 50)...), math.MaxInt32/8)
+	jump := int(50 * float64(sampleRate) / rate.fps())
+
+	track := buildLTCTrack(decodeLTC(samples))
+	if track == nil || len(track.Segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %v", track)
+	}
+	if d := track.Segments[1].Start - samplesToDuration(sampleRate, jump); d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("Expected discontinuity at %s, got %s", samplesToDuration(sampleRate, jump), track.Segments[1].Start)
+	}
+	if tc := track.TimecodeAt(track.Segments[1].Start); tc != "01:00:00:00" {
+		t.Errorf("Expected timecode after the jump to be 01:00:00:00, got %s", tc)
+	}
+
+	m := newLTCMetadata(track)
+	if len(m.Discontinuities) != 1 || m.Discontinuities[0].Expected != rate.timecode(1050) {
+		t.Errorf("Incorrect discontinuities: %v", m.Discontinuities)
+	}
+	loaded, err := m.track()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Segments) != 2 || loaded.Segments[1].StartFrame != 90000 {
+		t.Errorf("Incorrect segments loaded: %v", loaded.Segments)
+	}
+}
+
+func TestLTCInvalidFramesLoggedOnce(t *testing.T) {
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stderr)
+
+	rate, _ := parseFrameRate("24")
+	track := &LTCTrack{Rate: rate}
+	// 30 fps timecode read at 24 fps has frames that don't exist
+	for i := 0; i < 10; i++ {
+		track.add(ltcFrame{Position: i * 1470, Seconds: i / 30, Frames: 25 + i%5})
+	}
+	track.add(ltcFrame{Position: 20000, Seconds: 1})
+	if n := strings.Count(b.String(), "\n"); n != 2 {
+		t.Errorf("Expected one report of the invalid frames and one when they end, got:\n%s", b.String())
+	}
+	if len(track.Segments) != 1 {
+		t.Errorf("Expected the valid frame to be added, got %v", track.Segments)
+	}
+}
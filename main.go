@@ -32,6 +32,8 @@ var GOOD_COLOR = cell.ColorNumber(40)
 var METADATA_COLOR = cell.ColorNumber(247)
 var SYNC_COLOR = cell.ColorNumber(33)
 var SYNC_OFFSET_COLOR = cell.ColorNumber(226)
+var SPLIT_COLOR = cell.ColorNumber(201)
+var CURSOR_COLOR = cell.ColorNumber(244)
 
 var selectedChunk uint
 var selectedTake int
@@ -48,6 +50,15 @@ type widgets struct {
 
 var ui widgets
 
+// Message displayed after the controls, used to report problems to the user.
+var statusMessage string
+
+func setStatus(format string, a ...interface{}) {
+	statusMessage = fmt.Sprintf(format, a...)
+	log.Print(statusMessage)
+	updateControlsDisplay()
+}
+
 func IgnoreValueFormatter(value float64) string {
 	return ""
 }
@@ -133,18 +144,40 @@ func buildLayout(t *tcell.Terminal) *container.Container {
 
 func updateControlsDisplay() {
 	ui.controls.Reset()
+	if activePrompt != nil {
+		ui.controls.Write(activePrompt.label+": ", text.WriteCellOpts(
+			cell.Inverse(),
+		))
+		ui.controls.Write(string(activePrompt.text) + "█  ")
+		ui.controls.Write("Enter to confirm, Esc to cancel", text.WriteCellOpts(
+			cell.FgColor(METADATA_COLOR),
+		))
+		return
+	}
 	keybinds := getAvailableKeybinds()
 	for _, bind := range keybinds {
+		if bind.hidden {
+			continue
+		}
 		ui.controls.Write(fmt.Sprintf("%s", bind.key), text.WriteCellOpts(
 			cell.Inverse(),
 		))
 		ui.controls.Write(fmt.Sprintf(" %s  ", bind.desc))
 	}
+	if statusMessage != "" {
+		ui.controls.Write(statusMessage, text.WriteCellOpts(
+			cell.FgColor(SELECT_COLOR),
+		))
+	}
 }
 
 func globalKeyboardHandler(k *terminalapi.Keyboard) {
-	if k.Key == keyboard.KeyEsc || k.Key == keyboard.KeyCtrlC {
-		portaudio.Terminate()
+	if activePrompt != nil {
+		activePrompt.handleKey(k.Key)
+	} else if k.Key == keyboard.KeyEsc || k.Key == keyboard.KeyCtrlC {
+		if portaudioInitialized {
+			portaudio.Terminate()
+		}
 		terminal.Close()
 		cancelGlobal()
 	} else {
@@ -199,9 +232,36 @@ func main() {
 	}
 	log.SetOutput(f)
 	scriptFile := flag.String("script", "", "Path to the markdown file to use as input.")
+	flag.DurationVar(&programGap, "program-gap", programGap, "Silence between chunks when playing the program.")
+	flag.StringVar(&playbackSinkName, "playback-sink", playbackSinkName, "Where played back audio goes: portaudio, null, or file:<path to wav>. Later playbacks to a file are numbered instead of overwriting it.")
+	flag.Float64Var(&videoFrameRate, "fps", videoFrameRate, "Frame rate of the video, used when nudging take boundaries by frame.")
+	flag.DurationVar(&inputLatencyCompensation, "input-latency", inputLatencyCompensation, "Subtracted from take boundaries to compensate for the delay between pressing a key and it being handled.")
+	flag.StringVar(&syncReferencePath, "sync-reference", "", "Path to a wav file of reference audio, like a camera's scratch audio, to sync the session to.")
+	timecode := flag.String("timecode", "", "Frame rate to also write times as SMPTE timecodes at: 23.976, 24, 25, 29.97df, 29.97ndf, 30, 50 or 60.")
+	startTimecodeFlag := flag.String("start-timecode", "00:00:00:00", "Timecode of the sync peak, which synced times count from. Requires -timecode.")
+	flag.BoolVar(&ltcInputChannel, "ltc-channel", false, "Decode linear timecode from the second input channel while recording.")
+	flag.StringVar(&ltcFilePath, "ltc-file", "", "Path to a wav file of linear timecode recorded alongside a session, starting with it, to decode. The last channel is used. Requires -session.")
+	flag.BoolVar(&slateAtStart, "slate", false, "Play a slate tone when the session starts, and sync to it.")
+	exportFormats := flag.String("export", "", "Comma separated formats to export a finished session to: "+exporterNames()+". Requires -session.")
+	flag.BoolVar(&exportRejected, "export-rejected", exportRejected, "Include takes marked bad as disabled clips in exports that support it.")
+	flag.StringVar(&exportCamera, "export-camera", "", "Name of the camera that exported timings are relative to, instead of the main camera.")
+	sessionId := flag.Int("session", -1, "ID of a finished session to run commands like -sync-reference, -ltc-file or -export on, instead of recording a new session. Requires -script.")
 	listSessions := flag.Bool("list", false, "List sessions you've recorded. Requires `sessions` folder to be present in your current directory.")
 	flag.Parse()
 
+	if *timecode != "" {
+		err = setTimecodeFormat(*timecode, *startTimecodeFlag)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fpsSet := false
+		flag.Visit(func(f *flag.Flag) { fpsSet = fpsSet || f.Name == "fps" })
+		if !fpsSet {
+			videoFrameRate = timecodeRate.fps()
+		}
+	}
+
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Panic: %v", r)
@@ -214,8 +274,16 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *sessionId >= 0 {
+		err = runSessionCommands(*sessionId, *scriptFile, *exportFormats)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	fmt.Println("Initializing...")
-	initPortAudio()
 
 	terminal, err = tcell.New(tcell.ColorMode(terminalapi.ColorMode256))
 	if err != nil {
@@ -242,6 +310,9 @@ func main() {
 
 	StartSession()
 	go audioProcessor()
+	if slateAtStart {
+		go playSlateAtStart()
+	}
 
 	log.Print("Running termdash")
 	if err := termdash.Run(ctxGlobal, terminal, c, termdash.KeyboardSubscriber(globalKeyboardHandler), termdash.MouseSubscriber(globalMouseHandler), termdash.RedrawInterval(10*time.Millisecond)); err != nil {
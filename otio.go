@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Key that take metadata is stored under in OpenTimelineIO metadata, so it doesn't clash with other tools.
+const otioMetadataKey = "teleprompt_studio"
+
+type otioRationalTime struct {
+	Schema string  `json:"OTIO_SCHEMA"`
+	Rate   float64 `json:"rate"`
+	Value  float64 `json:"value"`
+}
+
+type otioTimeRange struct {
+	Schema    string           `json:"OTIO_SCHEMA"`
+	StartTime otioRationalTime `json:"start_time"`
+	Duration  otioRationalTime `json:"duration"`
+}
+
+type otioTimeline struct {
+	Schema          string                 `json:"OTIO_SCHEMA"`
+	Name            string                 `json:"name"`
+	GlobalStartTime otioRationalTime       `json:"global_start_time"`
+	Metadata        map[string]interface{} `json:"metadata"`
+	Tracks          otioStack              `json:"tracks"`
+}
+
+type otioStack struct {
+	Schema      string                 `json:"OTIO_SCHEMA"`
+	Name        string                 `json:"name"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	SourceRange *otioTimeRange         `json:"source_range"`
+	Effects     []interface{}          `json:"effects"`
+	Markers     []otioMarker           `json:"markers"`
+	Enabled     bool                   `json:"enabled"`
+	Children    []otioTrack            `json:"children"`
+}
+
+type otioTrack struct {
+	Schema      string                 `json:"OTIO_SCHEMA"`
+	Name        string                 `json:"name"`
+	Kind        string                 `json:"kind"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	SourceRange *otioTimeRange         `json:"source_range"`
+	Effects     []interface{}          `json:"effects"`
+	Markers     []otioMarker           `json:"markers"`
+	Enabled     bool                   `json:"enabled"`
+	Children    []otioClip             `json:"children"`
+}
+
+// A clip, or a gap if it has no media reference.
+type otioClip struct {
+	Schema         string                 `json:"OTIO_SCHEMA"`
+	Name           string                 `json:"name"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	SourceRange    otioTimeRange          `json:"source_range"`
+	Effects        []interface{}          `json:"effects"`
+	Markers        []otioMarker           `json:"markers"`
+	Enabled        bool                   `json:"enabled"`
+	MediaReference *otioExternalReference `json:"media_reference,omitempty"`
+}
+
+type otioExternalReference struct {
+	Schema         string                 `json:"OTIO_SCHEMA"`
+	Name           string                 `json:"name"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	AvailableRange otioTimeRange          `json:"available_range"`
+	TargetURL      string                 `json:"target_url"`
+}
+
+type otioMarker struct {
+	Schema      string                 `json:"OTIO_SCHEMA"`
+	Name        string                 `json:"name"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Color       string                 `json:"color"`
+	MarkedRange otioTimeRange          `json:"marked_range"`
+}
+
+func otioRange(start, duration int, rate frameRate) otioTimeRange {
+	return otioTimeRange{
+		Schema:    "TimeRange.1",
+		StartTime: otioRationalTime{Schema: "RationalTime.1", Rate: rate.fps(), Value: float64(start)},
+		Duration:  otioRationalTime{Schema: "RationalTime.1", Rate: rate.fps(), Value: float64(duration)},
+	}
+}
+
+// Describes the take of a clip, for OpenTimelineIO metadata.
+func otioTakeMetadata(s *Session, c programClip, rate frameRate) map[string]interface{} {
+	take := c.Take
+	m := map[string]interface{}{
+		"header":       c.Header,
+		"chunk":        c.HeaderChunk,
+		"take":         c.TakeIndex,
+		"mark":         take.Mark.String(),
+		"circled":      take.Circled,
+		"rating":       take.Rating,
+		"content":      c.Content,
+		"synced_start": rate.timecode(exportFrame(c.Synced.Start)),
+		"synced_end":   rate.timecode(exportFrame(c.Synced.End)),
+		"tags":         append([]string{}, take.Tags...),
+		"note":         take.Note,
+		"audio_start":  take.Start.Seconds(),
+		"audio_end":    take.End.Seconds(),
+	}
+	if take.Camera != "" {
+		m["camera"] = take.Camera
+	}
+	if tc := s.Doc.LTC.TimecodeAt(take.Start); tc != "" {
+		m["ltc_start"] = tc
+		m["ltc_end"] = s.Doc.LTC.TimecodeAt(take.End)
+	}
+	return map[string]interface{}{otioMetadataKey: m}
+}
+
+// Writes an OpenTimelineIO timeline with the chosen take of every chunk laid out back to back in script order on one
+// audio track, as clips of the session audio. Headers are marked on the track, and takes are described in the clip
+// metadata. Takes marked bad are included as disabled clips on a second track if exportRejected is set.
+func writeOTIO(w io.Writer, s *Session) error {
+	clips, err := s.programClips(exportRejected)
+	if err != nil {
+		return err
+	}
+	audioURL, err := s.audioURL()
+	if err != nil {
+		return err
+	}
+	rate := exportFrameRate()
+	tcStart, err := rate.parseTimecode(timelineStart)
+	if err != nil {
+		return err
+	}
+	// source ranges are timecodes, so the session audio is available from the timecode it starts at
+	mediaStart, _ := s.mediaStartFrame()
+	available := otioRange(mediaStart, rate.durationToFrames(samplesToDuration(sampleRate, len(s.Audio))), rate)
+	newTrack := func(name string) otioTrack {
+		return otioTrack{
+			Schema:   "Track.1",
+			Name:     name,
+			Kind:     "Audio",
+			Metadata: map[string]interface{}{},
+			Effects:  []interface{}{},
+			Markers:  []otioMarker{},
+			Enabled:  true,
+			Children: []otioClip{},
+		}
+	}
+	// Tracks play their children back to back, so clips that start later than the end of the previous one are
+	// preceded by a gap.
+	addClip := func(track *otioTrack, end int, c timelineClip) {
+		if c.Start > end {
+			track.Children = append(track.Children, otioClip{
+				Schema:      "Gap.1",
+				Metadata:    map[string]interface{}{},
+				SourceRange: otioRange(0, c.Start-end, rate),
+				Effects:     []interface{}{},
+				Markers:     []otioMarker{},
+				Enabled:     true,
+			})
+		}
+		track.Children = append(track.Children, otioClip{
+			Schema:      "Clip.1",
+			Name:        fmt.Sprintf("%s %d take %d", c.Header, c.HeaderChunk, c.TakeIndex),
+			Metadata:    otioTakeMetadata(s, c.programClip, rate),
+			SourceRange: otioRange(c.In, c.Out-c.In, rate),
+			Effects:     []interface{}{},
+			Markers:     []otioMarker{},
+			Enabled:     !c.Rejected,
+			MediaReference: &otioExternalReference{
+				Schema:         "ExternalReference.1",
+				Name:           "audio.wav",
+				Metadata:       map[string]interface{}{},
+				AvailableRange: available,
+				TargetURL:      audioURL,
+			},
+		})
+	}
+
+	program, rejected, _ := layoutClips(clips)
+	tracks := []otioTrack{newTrack("Audio")}
+	end := 0
+	lastHeader := ""
+	for i, c := range program {
+		if i == 0 || c.Header != lastHeader {
+			tracks[0].Markers = append(tracks[0].Markers, otioMarker{
+				Schema:      "Marker.1",
+				Name:        c.Header,
+				Metadata:    map[string]interface{}{},
+				Color:       "RED",
+				MarkedRange: otioRange(c.Start, 0, rate),
+			})
+		}
+		addClip(&tracks[0], end, c)
+		lastHeader = c.Header
+		end = c.End()
+	}
+	if len(rejected) > 0 {
+		track := newTrack("Rejected")
+		end = 0
+		for _, c := range rejected {
+			addClip(&track, end, c)
+			end = c.End()
+		}
+		tracks = append(tracks, track)
+	}
+
+	timeline := otioTimeline{
+		Schema:          "Timeline.1",
+		Name:            fmt.Sprintf("Session %d", s.Id),
+		GlobalStartTime: otioRationalTime{Schema: "RationalTime.1", Rate: rate.fps(), Value: float64(tcStart)},
+		Metadata:        map[string]interface{}{},
+		Tracks: otioStack{
+			Schema:   "Stack.1",
+			Name:     "tracks",
+			Metadata: map[string]interface{}{},
+			Effects:  []interface{}{},
+			Markers:  []otioMarker{},
+			Enabled:  true,
+			Children: tracks,
+		},
+	}
+	b, err := json.MarshalIndent(timeline, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
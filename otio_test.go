@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteOTIO(t *testing.T) {
+	useExportTestSession(t)
+	currentSession.Doc.GetChunk(2).Takes[1].Tags = []string{"warm"}
+	currentSession.Doc.GetChunk(2).Takes[1].Rating = 4
+
+	var b bytes.Buffer
+	if err := writeOTIO(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	var timeline otioTimeline
+	if err := json.Unmarshal(b.Bytes(), &timeline); err != nil {
+		t.Fatal(err)
+	}
+	if timeline.Schema != "Timeline.1" || timeline.Name != "Session 7" {
+		t.Errorf("Incorrect timeline: %s %s", timeline.Schema, timeline.Name)
+	}
+	if timeline.GlobalStartTime.Rate != 30 || timeline.GlobalStartTime.Value != 108000 {
+		t.Errorf("Incorrect start time: %+v", timeline.GlobalStartTime)
+	}
+	if len(timeline.Tracks.Children) != 1 {
+		t.Fatalf("Expected 1 track, got %d", len(timeline.Tracks.Children))
+	}
+	track := timeline.Tracks.Children[0]
+	if track.Kind != "Audio" {
+		t.Errorf("Incorrect track kind: %s", track.Kind)
+	}
+
+	// source ranges are timecodes, moved on by a day since the audio starts before the sync peak at 00:00:00:00
+	expected := []struct {
+		name            string
+		start, duration float64
+		mark            string
+	}{
+		{"Intro 0 take 0", 2592030, 60, "good"},
+		{"Outro 0 take 1", 2592330, 45, "bad"},
+	}
+	if len(track.Children) != len(expected) {
+		t.Fatalf("Expected %d clips, got %d", len(expected), len(track.Children))
+	}
+	for i, c := range track.Children {
+		e := expected[i]
+		if c.Name != e.name || c.SourceRange.StartTime.Value != e.start || c.SourceRange.Duration.Value != e.duration {
+			t.Errorf("Clip %d: expected %+v, got %s %+v", i, e, c.Name, c.SourceRange)
+		}
+		if !strings.HasSuffix(c.MediaReference.TargetURL, "/sessions/7/audio.wav") || c.MediaReference.AvailableRange.Duration.Value != 450 ||
+			c.MediaReference.AvailableRange.StartTime.Value != 2591970 {
+			t.Errorf("Clip %d: incorrect media reference %+v", i, c.MediaReference)
+		}
+		m, _ := c.Metadata[otioMetadataKey].(map[string]interface{})
+		if m["mark"] != e.mark {
+			t.Errorf("Clip %d: incorrect metadata %v", i, c.Metadata)
+		}
+	}
+	m := track.Children[1].Metadata[otioMetadataKey].(map[string]interface{})
+	if m["circled"] != true || m["rating"] != 4.0 || m["synced_start"] != "00:00:11:00" || m["content"] != "Goodbye." {
+		t.Errorf("Incorrect take metadata: %v", m)
+	}
+	if tags, _ := m["tags"].([]interface{}); len(tags) != 1 || tags[0] != "warm" {
+		t.Errorf("Incorrect tags: %v", m["tags"])
+	}
+
+	if len(track.Markers) != 2 || track.Markers[0].Name != "Intro" || track.Markers[1].Name != "Outro" ||
+		track.Markers[1].MarkedRange.StartTime.Value != 60 {
+		t.Errorf("Incorrect header markers: %+v", track.Markers)
+	}
+
+	// the rejected take of the outro has its own track, after a gap to where the outro starts
+	exportRejected = true
+	currentSession.Doc.GetChunk(0).Takes[1].Mark = Unmarked
+	currentSession.Doc.GetChunk(2).Takes[0].Mark = Bad
+	b.Reset()
+	if err := writeOTIO(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	var withRejected otioTimeline
+	if err := json.Unmarshal(b.Bytes(), &withRejected); err != nil {
+		t.Fatal(err)
+	}
+	tracks := withRejected.Tracks.Children
+	if len(tracks) != 2 {
+		t.Fatalf("Expected a track for rejected takes, got %d tracks", len(tracks))
+	}
+	if !reflect.DeepEqual(tracks[0], track) {
+		t.Errorf("Rejected takes should not change the program, got %+v", tracks[0])
+	}
+	rejected := tracks[1].Children
+	if len(rejected) != 2 || rejected[0].Schema != "Gap.1" || rejected[0].SourceRange.Duration.Value != 60 {
+		t.Fatalf("Expected a gap before the rejected take, got %+v", rejected)
+	}
+	if rejected[1].Name != "Outro 0 take 0" || rejected[1].Enabled || rejected[1].SourceRange.StartTime.Value != 2592270 {
+		t.Errorf("Incorrect rejected clip: %+v", rejected[1])
+	}
+}
+
+func TestWriteOTIOStartTimecode(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+
+	var b bytes.Buffer
+	if err := writeOTIO(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	var timeline otioTimeline
+	if err := json.Unmarshal(b.Bytes(), &timeline); err != nil {
+		t.Fatal(err)
+	}
+	// source ranges are timecodes from the start timecode, in audio that starts a second before it
+	clips := timeline.Tracks.Children[0].Children
+	if len(clips) != 2 || clips[0].SourceRange.StartTime.Value != 900025 || clips[1].SourceRange.StartTime.Value != 900275 {
+		t.Fatalf("Incorrect source ranges: %+v", clips)
+	}
+	if start := clips[0].MediaReference.AvailableRange.StartTime.Value; start != 899975 {
+		t.Errorf("Incorrect available range start: %v", start)
+	}
+}
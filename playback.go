@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Silence between takes when auditioning takes back to back.
+const compareGap = 500 * time.Millisecond
+
+// Silence between chunks when playing the program.
+var programGap time.Duration = time.Second
+
+var isComparing bool = false
+var isPlayingProgram bool = false
+
+// Index of the take currently being auditioned in compare mode.
+var comparingTake int = -1
+
+// Returns the indexes of the takes of the chunk that compare mode plays, in the order they are played.
+func compareQueue(chunk *Chunk, goodOnly bool) []int {
+	var queue []int
+	for i, take := range chunk.Takes {
+		if goodOnly && take.Mark != Good {
+			continue
+		}
+		queue = append(queue, i)
+	}
+	return queue
+}
+
+// Plays the takes of the selected chunk back to back so they can be compared. Each take
+// becomes the selected take while it plays, so it can be marked with a single key press.
+func compareTakes(goodOnly bool) {
+	if isPlaying || isComparing {
+		return
+	}
+	isComparing = true
+	stopPlaybackRequested = false
+	defer func() {
+		isComparing = false
+		comparingTake = -1
+	}()
+
+	chunkIdx := selectedChunk
+	chunk := currentSession.Doc.GetChunk(int(chunkIdx))
+	log.Printf("comparing %d takes of chunk %d", len(chunk.Takes), chunkIdx)
+	for _, i := range compareQueue(chunk, goodOnly) {
+		if stopPlaybackRequested || selectedChunk != chunkIdx {
+			break
+		}
+		selectedTake = i
+		comparingTake = i
+		playbackTake(chunk.Takes[i])
+		time.Sleep(compareGap)
+	}
+	log.Printf("compare complete")
+}
+
+// Plays the chosen take of every chunk in script order, so the assembled voice over can be
+// heard as it would be edited. Chunks without a usable take are skipped and reported.
+func playProgram() {
+	if isPlaying || isComparing || isPlayingProgram {
+		return
+	}
+	isPlayingProgram = true
+	stopPlaybackRequested = false
+	defer func() {
+		isPlayingProgram = false
+	}()
+
+	var skipped []string
+	for i := 0; i < currentSession.Doc.CountChunks(); i++ {
+		if stopPlaybackRequested {
+			break
+		}
+		chunk := currentSession.Doc.GetChunk(i)
+		t := chunk.ChosenTake()
+		if t < 0 {
+			skipped = append(skipped, fmt.Sprintf("%d", i+1))
+			continue
+		}
+		selectedChunk = uint(i)
+		selectedTake = t
+		playbackTake(chunk.Takes[t])
+		time.Sleep(programGap)
+	}
+
+	if len(skipped) > 0 {
+		setStatus("Program skipped %d chunk(s) with no usable take: %s", len(skipped), strings.Join(skipped, ", "))
+	} else {
+		log.Printf("program playback complete")
+	}
+}
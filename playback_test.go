@@ -0,0 +1,24 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareQueue(t *testing.T) {
+	chunk := Chunk{Takes: []Take{
+		{Mark: Good},
+		{Mark: Bad},
+		{Mark: Unmarked},
+		{Mark: Good},
+	}}
+	if q := compareQueue(&chunk, false); !reflect.DeepEqual(q, []int{0, 1, 2, 3}) {
+		t.Errorf("Expected every take in recording order, got %v", q)
+	}
+	if q := compareQueue(&chunk, true); !reflect.DeepEqual(q, []int{0, 3}) {
+		t.Errorf("Expected the good takes in recording order, got %v", q)
+	}
+	if q := compareQueue(&Chunk{}, false); len(q) != 0 {
+		t.Errorf("Expected no takes, got %v", q)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/mum4k/termdash/keyboard"
+)
+
+// A single line text input shown in place of the controls. While a prompt is open,
+// it receives all key presses instead of the keybinds.
+type inputPrompt struct {
+	label    string
+	text     []rune
+	callback func(string)
+}
+
+var activePrompt *inputPrompt
+
+// Opens a prompt with the initial text. The callback is called with the entered text
+// when the user presses enter, and is not called if the prompt is cancelled.
+func openPrompt(label string, initial string, callback func(string)) {
+	activePrompt = &inputPrompt{
+		label:    label,
+		text:     []rune(initial),
+		callback: callback,
+	}
+}
+
+func (p *inputPrompt) handleKey(k keyboard.Key) {
+	switch {
+	case k == keyboard.KeyEnter:
+		activePrompt = nil
+		p.callback(string(p.text))
+	case k == keyboard.KeyEsc:
+		activePrompt = nil
+	case k == keyboard.KeyBackspace || k == keyboard.KeyBackspace2:
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	case k >= ' ' && k != keyboard.KeyBackspace2:
+		p.text = append(p.text, rune(k))
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"log"
+	"math"
 	"time"
 
 	"github.com/go-audio/audio"
@@ -17,23 +18,38 @@ var isRecording bool = false
 var audioStream chan []int32 = make(chan []int32, 10)
 var currentSession Session
 var isPlaying bool = false
+
+// Set to request that the current playback stops early.
+var stopPlaybackRequested bool = false
 var audioDiskStream *wav.Encoder
 
 // Playback position in samples
 var playbackPosition int = 0
 
+const minPlaybackSpeed = 0.5
+const maxPlaybackSpeed = 2.0
+
+// Speed factor that audio is played back at.
+var playbackSpeed float64 = 1.0
+
 var portaudioInitialized bool = false
 
-func initPortAudio() {
+// Index of the sync take that ended last, until audioProcessor has received the audio up to its end and its sync peak
+// has been detected. Take boundaries are timestamped ahead of the audio, so it isn't all there when the take ends.
+var pendingSyncTake int = -1
+var pendingSyncEnd time.Duration
+
+func initPortAudio() error {
 	var err error
 	o, _ := osutil.CaptureWithCGo(func() {
 		err = portaudio.Initialize()
 	})
 	log.Printf(string(o))
 	if err != nil {
-		log.Fatalf("Failed to initialize recording: %s", err)
+		return err
 	}
 	portaudioInitialized = true
+	return nil
 }
 
 func record() {
@@ -42,8 +58,19 @@ func record() {
 	// This is based on the record example shown in the portaudio repo.
 	// It's unclear whether or not framesPerBuffer should match the buffer size
 	// or be zero (where portaudio will provide variable length buffers).
-	in := make([]int32, bufSize)
-	stream, err := portaudio.OpenDefaultStream(1, 0, sampleRate, len(in), in)
+	channels := 1
+	if ltcInputChannel {
+		channels = 2
+	}
+	in := make([]int32, bufSize*channels)
+	if !portaudioInitialized {
+		err := initPortAudio()
+		if err != nil {
+			setStatus("Failed to initialize recording: %s", err)
+			return
+		}
+	}
+	stream, err := portaudio.OpenDefaultStream(channels, 0, sampleRate, bufSize, in)
 	if err != nil {
 		log.Fatalf("Failed to open stream audio: %s", err)
 	}
@@ -54,19 +81,45 @@ func record() {
 		log.Fatalf("Failed to start stream audio: %s", err)
 	}
 
+	var inputLatency time.Duration
+	if info := stream.Info(); info != nil {
+		inputLatency = info.InputLatency
+	}
+	recordingClock.Reset()
+	samplesRead := 0
+
 	isRecording = true
 	log.Print("Recording started")
 	for {
 		// wait for enough audio to fill the buffer
-		for avail := 0; avail < len(in); avail, _ = stream.AvailableToRead() {
-			time.Sleep(time.Second / sampleRate * time.Duration(len(in)-avail) / 2)
+		for avail := 0; avail < bufSize; avail, _ = stream.AvailableToRead() {
+			time.Sleep(time.Second / sampleRate * time.Duration(bufSize-avail) / 2)
 		}
 
 		err := stream.Read()
 		if err != nil {
 			log.Fatalf("Failed to read stream audio: %s", err)
 		}
-		audioStream <- in
+		// The last sample that was read reached the ADC before the samples that are still waiting to be read,
+		// and before the stream's input latency.
+		now := time.Now()
+		samplesRead += bufSize
+		waiting, _ := stream.AvailableToRead()
+		recordingClock.Observe(samplesRead, now.Add(-inputLatency-samplesToDuration(sampleRate, waiting)))
+
+		// in is reused for the next read, so the buffers waiting in audioStream need their own copy.
+		buffer := make([]int32, bufSize)
+		if ltcInputChannel {
+			ltc := make([]int32, bufSize)
+			for i := range buffer {
+				buffer[i] = in[i*channels]
+				ltc[i] = in[i*channels+1]
+			}
+			ltcStream <- ltc
+		} else {
+			copy(buffer, in)
+		}
+		audioStream <- buffer
 		if !isRecording {
 			break
 		}
@@ -82,6 +135,9 @@ func StartSession() {
 		log.Fatalf("Failed to streaming to disk: %s", err)
 	}
 	if !isRecording {
+		if ltcInputChannel {
+			go ltcProcessor()
+		}
 		go record()
 	}
 }
@@ -115,6 +171,7 @@ func audioProcessor() {
 			I32:            buffer,
 		}
 		audioDiskStream.Write(buf.AsIntBuffer())
+		detectPendingSync()
 
 		if isRecordingTake {
 			if isRecordingSyncTake {
@@ -127,45 +184,75 @@ func audioProcessor() {
 	}
 }
 
-func playbackTimespan(timespan TimeSpan) {
-	isPlaying = true
-	log.Printf("playing back %d samples...", len(currentSession.Audio))
-	// This is based on the play example shown in the portaudio repo.
-	const bufSize = 1024
-
-	if !portaudioInitialized {
-		initPortAudio()
+// Detects the sync peak of the sync take that ended last, once the audio up to its end has been received.
+func detectPendingSync() {
+	if pendingSyncTake < 0 || len(currentSession.Audio) < durationToSamples(sampleRate, pendingSyncEnd) {
+		return
+	}
+	i := pendingSyncTake
+	pendingSyncTake = -1
+	if i >= len(currentSession.Doc.syncTakes) {
+		return
 	}
+	err := resyncEditedTake(&currentSession.Doc.syncTakes[i])
+	if err != nil {
+		setStatus("%s", err)
+	}
+	currentSession.FullSave()
+}
 
-	out := make([]int32, bufSize)
-	stream, err := portaudio.OpenDefaultStream(0, 1, sampleRate, len(out), &out)
+func playbackTimespan(timespan TimeSpan) {
+	sink, err := openPlaybackSink()
 	if err != nil {
-		log.Fatalf("Failed to open stream audio: %s", err)
+		setStatus("Failed to open audio output: %s", err)
+		return
 	}
-	defer stream.Close()
-	log.Printf("stream open: %v", stream.Info())
+	defer sink.Close()
 
-	err = stream.Start()
+	err = playSamples(sink, currentSession.Audio, timespan)
 	if err != nil {
-		log.Fatalf("Failed to start stream audio: %s", err)
+		setStatus("Failed to play audio: %s", err)
 	}
-	defer stream.Stop()
-	log.Printf("stream started")
+}
 
-	start := durationToSamples(sampleRate, timespan.Start)
-	end := durationToSamples(sampleRate, timespan.End)
-	samples := currentSession.Audio[start:end]
-	for b := 0; b < len(samples); b += len(out) {
-		playbackPosition = start + b
-		out = samples[b:clamp(b+bufSize, 0, len(samples))]
-		err := stream.Write()
+// Plays the timespan of the audio to the sink, updating playbackPosition as it goes.
+func playSamples(sink AudioSink, samples []int32, timespan TimeSpan) error {
+	const bufSize = 1024
+
+	isPlaying = true
+	defer func() {
+		isPlaying = false
+	}()
+	stopPlaybackRequested = false
+	d := timespan.Duration()
+	log.Printf("playing back %s...", Timestamp(&d))
+
+	start := clamp(durationToSamples(sampleRate, timespan.Start), 0, len(samples))
+	end := clamp(durationToSamples(sampleRate, timespan.End), start, len(samples))
+	stretched := timeStretch(samples[start:end], playbackSpeed)
+	// timeStretch leaves segments that are too short to stretch unchanged, so positions are scaled by the actual length
+	scale := 1.0
+	if len(stretched) > 0 {
+		scale = float64(end-start) / float64(len(stretched))
+	}
+	for b := 0; b < len(stretched); b += bufSize {
+		if stopPlaybackRequested {
+			log.Printf("playback stopped")
+			return nil
+		}
+		playbackPosition = start + int(float64(b)*scale)
+		err := sink.Write(stretched[b:clamp(b+bufSize, 0, len(stretched))])
 		if err != nil {
-			log.Printf("Failed to write stream audio: %v", err)
+			return err
 		}
 	}
 
 	log.Printf("playback complete")
-	isPlaying = false
+	return nil
+}
+
+func changePlaybackSpeed(delta float64) {
+	playbackSpeed = math.Max(minPlaybackSpeed, math.Min(maxPlaybackSpeed, playbackSpeed+delta))
 }
 
 func playbackTake(take Take) {
@@ -180,7 +267,8 @@ func samplesToDuration(sampleRate int, nSamples int) time.Duration {
 }
 
 func durationToSamples(sampleRate int, d time.Duration) int {
-	return int(d.Seconds() * float64(sampleRate))
+	// Rounded, because samplesToDuration truncates to the nanosecond.
+	return int(math.Round(d.Seconds() * float64(sampleRate)))
 }
 
 type TimeSpan struct {
@@ -195,14 +283,30 @@ func (t *TimeSpan) Duration() time.Duration {
 type Take struct {
 	TimeSpan
 	Mark TakeMark
+	// Star rating from 1 to 5, or 0 if the take has not been rated.
+	Rating uint8
+	Tags   []string
+	Note   string
+	// Marks the take picked for the edit. At most one take per chunk is circled.
+	Circled bool
+	// For sync takes after the first, the time of the sync peak on the camera, relative to the first sync peak.
+	// Zero if it is not known.
+	CameraTime time.Duration
+	// For sync takes, the name of the camera the sync take is for, or empty for the main camera.
+	Camera string
 }
 
 func startTake(sync bool) error {
 	if isRecordingTake {
 		return errors.New("Already recording take")
 	}
+	if sync {
+		recordHistory("Sync Take")
+	} else {
+		recordHistory("Take")
+	}
 	take := Take{}
-	take.Start = samplesToDuration(sampleRate, len(currentSession.Audio))
+	take.Start = keypressTimestamp()
 	if sync {
 		take.Mark = Sync
 		currentSession.Doc.syncTakes = append(currentSession.Doc.syncTakes, take)
@@ -221,17 +325,22 @@ func endTake() error {
 	if !isRecordingTake {
 		return errors.New("Not recording take")
 	}
+	end := keypressTimestamp()
+	// Stop audioProcessor from extending the take before setting its final end.
+	isRecordingTake = false
 	if isRecordingSyncTake {
-		currentSession.Doc.syncTakes[selectedTake].End = samplesToDuration(sampleRate, len(currentSession.Audio))
+		currentSession.Doc.syncTakes[selectedTake].End = end
 		isRecordingSyncTake = false
-		if currentSession.Doc.SyncOffset == time.Duration(0) {
-			currentSession.updateSyncOffset()
+		pendingSyncTake = selectedTake
+		pendingSyncEnd = end
+		if !viewingSyncTakes {
+			// the chunk's takes are shown, so select among them again
+			selectedTake = len(currentSession.Doc.GetChunk(int(selectedChunk)).Takes) - 1
 		}
 	} else {
 		chunk := currentSession.Doc.GetChunk(int(selectedChunk))
-		chunk.Takes[selectedTake].End = samplesToDuration(sampleRate, len(currentSession.Audio))
+		chunk.Takes[selectedTake].End = end
 	}
-	isRecordingTake = false
 	currentSession.FullSave()
 	return nil
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"math"
+	"os"
 	"testing"
 	"time"
 )
@@ -18,3 +20,61 @@ func TestSamplesToDuration(t *testing.T) {
 		t.Errorf("Incorrect samples to duration")
 	}
 }
+
+func TestDurationToSamples(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 44099, 44100, 44101, 1234567} {
+		if durationToSamples(44100, samplesToDuration(44100, n)) != n {
+			t.Errorf("Expected %d samples to survive a round trip through a duration", n)
+		}
+	}
+}
+
+func TestDetectPendingSync(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	defer func() { pendingSyncTake = -1 }()
+
+	audio := make([]int32, sampleRate*2)
+	addClap(audio, sampleRate, math.MaxInt32/2, false)
+	currentSession = Session{Audio: audio[:sampleRate]}
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 500 * time.Millisecond, End: 1500 * time.Millisecond}, Mark: Sync},
+	}
+	pendingSyncTake, pendingSyncEnd = 0, 1500*time.Millisecond
+
+	// the clap hasn't been received yet
+	detectPendingSync()
+	if pendingSyncTake != 0 || currentSession.Doc.SyncOffset != 0 {
+		t.Errorf("Expected detection to wait for the end of the sync take, got offset %s", currentSession.Doc.SyncOffset)
+	}
+
+	currentSession.Audio = audio
+	detectPendingSync()
+	if pendingSyncTake != -1 {
+		t.Errorf("Expected the pending sync take to be cleared")
+	}
+	if d := currentSession.Doc.SyncOffset - time.Second; d < 0 || d > time.Millisecond {
+		t.Errorf("Expected detected sync offset at 1s, got %s", currentSession.Doc.SyncOffset)
+	}
+}
+
+func TestEndSyncTakeSelection(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	defer func() { pendingSyncTake = -1 }()
+
+	currentSession = Session{Doc: parseDoc("# Intro\nchunk 1"), Audio: make([]int32, sampleRate)}
+	currentSession.Doc.GetChunk(0).Takes = []Take{{TimeSpan: TimeSpan{Start: 0, End: 100 * time.Millisecond}, Mark: Good}}
+	currentSession.Doc.syncTakes = []Take{{Mark: Sync}, {Mark: Sync}}
+	selectedChunk, selectedTake, viewingSyncTakes = 0, 1, false
+	isRecordingTake, isRecordingSyncTake = true, true
+
+	if err := endTake(); err != nil {
+		t.Fatal(err)
+	}
+	if selectedTake != 0 {
+		t.Errorf("Expected the chunk's take to be selected after the sync take ended, got %d", selectedTake)
+	}
+}
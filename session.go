@@ -4,9 +4,14 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
@@ -25,18 +30,100 @@ type Session struct {
 }
 
 func (s *Session) ExtractAudio(timespan TimeSpan) []int32 {
-	startIdx := durationToSamples(sampleRate, timespan.Start)
-	endIdx := durationToSamples(sampleRate, timespan.End)
+	// The end of a take may not have been recorded yet
+	endIdx := clamp(durationToSamples(sampleRate, timespan.End), 0, len(s.Audio))
+	startIdx := clamp(durationToSamples(sampleRate, timespan.Start), 0, endIdx)
 	return s.Audio[startIdx:endIdx]
 }
 
-func (s *Session) updateSyncOffset() {
-	// take the audio from the first sync take, find peak, set Doc.syncOffset
-	t := s.Doc.syncTakes[0].TimeSpan
-	a := s.ExtractAudio(t)
-	peakIdx := indexOfMaxInt32(a)
-	relOffset := samplesToDuration(sampleRate, peakIdx)
-	s.Doc.SyncOffset = t.Start + relOffset
+// Finds the sync peak in the first sync take, and sets the sync offset to it. Returns an error,
+// and leaves the session unsynced, if the sync peak can't be told apart from other transients.
+func (s *Session) updateSyncOffset() error {
+	s.Doc.SyncOffset = 0
+	s.Doc.SyncDrift = 0
+	s.Doc.SyncConfidence = 0
+	s.Doc.SyncManual = false
+	s.Doc.syncCandidates = nil
+	t, ok := s.Doc.firstSyncSpan()
+	if !ok {
+		return nil
+	}
+
+	d := detectSyncPeak(s.ExtractAudio(t))
+	s.Doc.SyncConfidence = d.Confidence
+	for _, idx := range append([]int{d.Index}, d.RunnersUp...) {
+		s.Doc.syncCandidates = append(s.Doc.syncCandidates, t.Start+samplesToDuration(sampleRate, idx))
+	}
+	if d.Confidence < minSyncConfidence {
+		return fmt.Errorf("Sync take is ambiguous (%.0f%% confidence), record it again", d.Confidence*100)
+	}
+	s.Doc.SyncOffset = s.Doc.syncCandidates[0]
+	return s.updateSyncDrift()
+}
+
+// Places the sync peak by hand, for when it can't be detected. Returns an error if the drift
+// can no longer be corrected with the new sync offset.
+func (s *Session) setSyncOffset(t time.Duration) error {
+	recorded := samplesToDuration(sampleRate, len(s.Audio))
+	if t < 0 {
+		t = 0
+	} else if t > recorded {
+		t = recorded
+	}
+	s.Doc.SyncOffset = t
+	s.Doc.SyncConfidence = 1
+	s.Doc.SyncManual = true
+	s.Doc.syncCandidates = nil
+	return s.updateSyncDrift()
+}
+
+// Finds the sync peak of every named camera in the first sync take for it. Returns an error for
+// the first camera whose sync peak can't be told apart from other transients.
+func (s *Session) updateCameraSyncs() error {
+	s.Doc.Cameras = nil
+	var err error
+	for i, take := range s.Doc.syncTakes {
+		if take.Camera == "" || s.Doc.firstSyncTake(take.Camera) != i {
+			continue
+		}
+		d := detectSyncPeak(s.ExtractAudio(take.TimeSpan))
+		s.Doc.Cameras = append(s.Doc.Cameras, CameraSync{
+			Name:       take.Camera,
+			Offset:     take.Start + samplesToDuration(sampleRate, d.Index),
+			Confidence: d.Confidence,
+		})
+		if d.Confidence < minSyncConfidence && err == nil {
+			err = fmt.Errorf("Sync take for camera %s is ambiguous (%.0f%% confidence), record it again", take.Camera, d.Confidence*100)
+		}
+	}
+	return err
+}
+
+// Drift between clocks beyond this is assumed to be a mistake.
+const maxSyncDrift = 0.01
+
+// Estimates the clock drift from the last sync take that has a camera time. The drift is the ratio of the time
+// between the sync peaks on the camera, to the time between them in the recorded audio.
+func (s *Session) updateSyncDrift() error {
+	s.Doc.SyncDrift = 0
+	for i := len(s.Doc.syncTakes) - 1; i > 0; i-- {
+		take := s.Doc.syncTakes[i]
+		if take.CameraTime == 0 || take.Camera != "" || i == s.Doc.firstSyncTake("") {
+			continue
+		}
+		d := detectSyncPeak(s.ExtractAudio(take.TimeSpan))
+		if d.Confidence < minSyncConfidence {
+			return fmt.Errorf("Sync take %d is ambiguous (%.0f%% confidence), drift can't be corrected", i, d.Confidence*100)
+		}
+		recorded := take.Start + samplesToDuration(sampleRate, d.Index) - s.Doc.SyncOffset
+		drift := float64(take.CameraTime) / float64(recorded)
+		if recorded <= 0 || math.Abs(drift-1) > maxSyncDrift {
+			return fmt.Errorf("Sync take %d implies an unlikely clock drift, check its camera time", i)
+		}
+		s.Doc.SyncDrift = drift
+		return nil
+	}
+	return nil
 }
 
 // Derive the session ID by checking how many sessions exist in the directory.
@@ -112,18 +199,30 @@ func (s *Session) saveTakes() error {
 	defer takesFile.Close()
 	w := csv.NewWriter(takesFile)
 	defer w.Flush()
-	err = w.Write([]string{"header", "chunk_index", "chunk_text", "take_index", "take_mark", "take_start", "take_end"})
+	// take_start and take_end are synced for reading, the takes are loaded from their sample positions in the audio
+	columns := []string{"header", "chunk_index", "chunk_text", "take_index", "take_mark", "take_start", "take_end", "take_rating", "take_tags", "take_note", "take_circled", "take_start_sample", "take_end_sample"}
+	if timecodeRate != nil {
+		columns = append(columns, "take_start_tc", "take_end_tc")
+	}
+	ltc := currentSession.Doc.LTC
+	if ltc != nil {
+		columns = append(columns, "take_ltc_start", "take_ltc_end")
+	}
+	// timings relative to each named camera, so that takes can be found in its footage
+	for _, camera := range currentSession.Doc.Cameras {
+		columns = append(columns, "take_start_"+camera.Name, "take_end_"+camera.Name)
+	}
+	err = w.Write(columns)
 	if err != nil {
 		log.Print("Failed to write takes header")
 		return err
 	}
-	syncOffset := currentSession.Doc.SyncOffset
 	for _, header := range currentSession.Doc.headers {
 		for c, chunk := range header.Chunks {
 			for t, take := range chunk.Takes {
-				syncedStart := take.Start - syncOffset
-				syncedEnd := take.End - syncOffset
-				err = w.Write([]string{
+				syncedStart := currentSession.Doc.SyncedTime(take.Start)
+				syncedEnd := currentSession.Doc.SyncedTime(take.End)
+				row := []string{
 					header.Text,
 					fmt.Sprintf("%d", c),
 					chunk.Content[:clamp(32, 0, len(chunk.Content))] + "...",
@@ -131,7 +230,25 @@ func (s *Session) saveTakes() error {
 					fmt.Sprintf("%s", take.Mark),
 					fmt.Sprintf("%s", Timestamp(&syncedStart)),
 					fmt.Sprintf("%s", Timestamp(&syncedEnd)),
-				})
+					fmt.Sprintf("%d", take.Rating),
+					strings.Join(take.Tags, ";"),
+					take.Note,
+					fmt.Sprintf("%t", take.Circled),
+					fmt.Sprintf("%d", durationToSamples(sampleRate, take.Start)),
+					fmt.Sprintf("%d", durationToSamples(sampleRate, take.End)),
+				}
+				if timecodeRate != nil {
+					row = append(row, formatSyncedTime(syncedStart), formatSyncedTime(syncedEnd))
+				}
+				if ltc != nil {
+					row = append(row, ltc.TimecodeAt(take.Start), ltc.TimecodeAt(take.End))
+				}
+				for _, camera := range currentSession.Doc.Cameras {
+					cameraStart, _ := currentSession.Doc.SyncedTimeFor(camera.Name, take.Start)
+					cameraEnd, _ := currentSession.Doc.SyncedTimeFor(camera.Name, take.End)
+					row = append(row, formatSyncedTime(cameraStart), formatSyncedTime(cameraEnd))
+				}
+				err = w.Write(row)
 				if err != nil {
 					log.Print("Failed to write takes")
 					return err
@@ -142,17 +259,77 @@ func (s *Session) saveTakes() error {
 	return nil
 }
 
+// Writes the sync takes to sync_takes.csv. They are kept out of takes.csv because they don't belong to a chunk, and
+// are written without the sync offset applied, since they are what it is derived from.
+func (s *Session) saveSyncTakes() error {
+	dir, err := s.getSessionDir()
+	if err != nil {
+		return err
+	}
+
+	syncFile, err := os.Create(path.Join(dir, "sync_takes.csv"))
+	if err != nil {
+		log.Print("Failed to create sync takes file")
+		return err
+	}
+	defer syncFile.Close()
+	w := csv.NewWriter(syncFile)
+	defer w.Flush()
+	columns := []string{"take_index", "take_start", "take_end", "take_rating", "take_tags", "take_note", "take_camera_time", "take_camera"}
+	ltc := currentSession.Doc.LTC
+	if ltc != nil {
+		columns = append(columns, "take_ltc_start", "take_ltc_end")
+	}
+	err = w.Write(columns)
+	if err != nil {
+		log.Print("Failed to write sync takes header")
+		return err
+	}
+	for t, take := range currentSession.Doc.syncTakes {
+		row := []string{
+			fmt.Sprintf("%d", t),
+			Timestamp(&take.Start),
+			Timestamp(&take.End),
+			fmt.Sprintf("%d", take.Rating),
+			strings.Join(take.Tags, ";"),
+			take.Note,
+			Timestamp(&take.CameraTime),
+			take.Camera,
+		}
+		if ltc != nil {
+			row = append(row, ltc.TimecodeAt(take.Start), ltc.TimecodeAt(take.End))
+		}
+		err = w.Write(row)
+		if err != nil {
+			log.Print("Failed to write sync takes")
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Session) saveMetadata() error {
 	dir, err := s.getSessionDir()
 	if err != nil {
 		return err
 	}
 
-	sessionMetadata, err := json.Marshal(
-		struct {
-			SyncOffset string `json:"SyncOffset"`
-		}{
-			Timestamp(&currentSession.Doc.SyncOffset),
+	var cameras []cameraMetadata
+	for _, c := range currentSession.Doc.Cameras {
+		cameras = append(cameras, cameraMetadata{
+			Name:       c.Name,
+			Offset:     Timestamp(&c.Offset),
+			Confidence: c.Confidence,
+		})
+	}
+	metadata, err := json.Marshal(
+		sessionMetadata{
+			SyncOffset:     Timestamp(&currentSession.Doc.SyncOffset),
+			SyncConfidence: currentSession.Doc.SyncConfidence,
+			SyncDrift:      currentSession.Doc.SyncDrift,
+			SyncManual:     currentSession.Doc.SyncManual,
+			Cameras:        cameras,
+			LTC:            newLTCMetadata(currentSession.Doc.LTC),
 		},
 	)
 	if err != nil {
@@ -165,7 +342,7 @@ func (s *Session) saveMetadata() error {
 		return err
 	}
 	defer metadataFile.Close()
-	metadataFile.Write(sessionMetadata)
+	metadataFile.Write(metadata)
 
 	return nil
 }
@@ -190,6 +367,12 @@ func (s *Session) FullSave() error {
 		return err
 	}
 
+	err = s.saveSyncTakes()
+	if err != nil {
+		log.Print("Failed to save sync takes")
+		return err
+	}
+
 	dir, err := s.getSessionDir()
 	log.Printf("Current session successfully saved: %s", dir)
 
@@ -198,6 +381,282 @@ func (s *Session) FullSave() error {
 	return nil
 }
 
+// The contents of metadata.json.
+type sessionMetadata struct {
+	SyncOffset     string  `json:"SyncOffset"`
+	SyncConfidence float64 `json:"SyncConfidence"`
+	SyncDrift      float64 `json:"SyncDrift"`
+	SyncManual     bool    `json:"SyncManual,omitempty"`
+	// The sync peaks of cameras other than the main one.
+	Cameras []cameraMetadata `json:"Cameras,omitempty"`
+	LTC     *ltcMetadata     `json:"LTC,omitempty"`
+}
+
+// Timecode decoded from LTC. Each segment is a run of consecutive timecodes, and every
+// segment after the first starts at a discontinuity.
+type ltcMetadata struct {
+	Rate            string               `json:"Rate"`
+	StartTimecode   string               `json:"StartTimecode"`
+	Segments        []ltcSegmentMetadata `json:"Segments"`
+	Discontinuities []ltcDiscontinuity   `json:"Discontinuities,omitempty"`
+}
+
+type ltcSegmentMetadata struct {
+	Start         string `json:"Start"`
+	StartTimecode string `json:"StartTimecode"`
+	End           string `json:"End"`
+	EndTimecode   string `json:"EndTimecode"`
+}
+
+// A jump in the timecode, at a position in the recording.
+type ltcDiscontinuity struct {
+	At       string `json:"At"`
+	Expected string `json:"Expected"`
+	Timecode string `json:"Timecode"`
+}
+
+func newLTCMetadata(track *LTCTrack) *ltcMetadata {
+	if track == nil || len(track.Segments) == 0 {
+		return nil
+	}
+	m := &ltcMetadata{
+		Rate:          track.Rate.String(),
+		StartTimecode: track.Rate.timecode(track.Segments[0].StartFrame),
+	}
+	for i, seg := range track.Segments {
+		m.Segments = append(m.Segments, ltcSegmentMetadata{
+			Start:         Timestamp(&seg.Start),
+			StartTimecode: track.Rate.timecode(seg.StartFrame),
+			End:           Timestamp(&seg.End),
+			EndTimecode:   track.Rate.timecode(seg.EndFrame),
+		})
+		if i > 0 {
+			m.Discontinuities = append(m.Discontinuities, ltcDiscontinuity{
+				At:       Timestamp(&seg.Start),
+				Expected: track.Rate.timecode(track.Segments[i-1].EndFrame + 1),
+				Timecode: track.Rate.timecode(seg.StartFrame),
+			})
+		}
+	}
+	return m
+}
+
+func (m *ltcMetadata) track() (*LTCTrack, error) {
+	rate, err := parseFrameRate(m.Rate)
+	if err != nil {
+		return nil, err
+	}
+	track := &LTCTrack{Rate: rate}
+	for _, seg := range m.Segments {
+		var s LTCSegment
+		if s.Start, err = ParseTimestamp(seg.Start); err != nil {
+			return nil, err
+		}
+		if s.End, err = ParseTimestamp(seg.End); err != nil {
+			return nil, err
+		}
+		if s.StartFrame, err = rate.parseTimecode(seg.StartTimecode); err != nil {
+			return nil, err
+		}
+		if s.EndFrame, err = rate.parseTimecode(seg.EndTimecode); err != nil {
+			return nil, err
+		}
+		track.Segments = append(track.Segments, s)
+	}
+	return track, nil
+}
+
+type cameraMetadata struct {
+	Name       string  `json:"Name"`
+	Offset     string  `json:"Offset"`
+	Confidence float64 `json:"Confidence"`
+}
+
+// Loads a finished session from disk. The document must be parsed from the script that the session was recorded with.
+func loadSession(id int, doc Document) (Session, error) {
+	s := Session{
+		Id:           id,
+		Doc:          doc,
+		hasBeenSaved: true,
+	}
+	dir := path.Join(SessionsFolder, fmt.Sprintf("%d", id))
+
+	audioFile, err := os.Open(path.Join(dir, "audio.wav"))
+	if err != nil {
+		return s, err
+	}
+	defer audioFile.Close()
+	buf, err := wav.NewDecoder(audioFile).FullPCMBuffer()
+	if err != nil {
+		return s, fmt.Errorf("Failed to read session audio: %s", err)
+	}
+	s.Audio = make([]int32, len(buf.Data))
+	for i, v := range buf.Data {
+		s.Audio[i] = int32(v)
+	}
+
+	b, err := ioutil.ReadFile(path.Join(dir, "metadata.json"))
+	if err != nil {
+		return s, err
+	}
+	var metadata sessionMetadata
+	err = json.Unmarshal(b, &metadata)
+	if err != nil {
+		return s, fmt.Errorf("Failed to read session metadata: %s", err)
+	}
+	s.Doc.SyncOffset, err = ParseTimestamp(metadata.SyncOffset)
+	if err != nil {
+		return s, err
+	}
+	s.Doc.SyncConfidence = metadata.SyncConfidence
+	s.Doc.SyncDrift = metadata.SyncDrift
+	s.Doc.SyncManual = metadata.SyncManual
+	for _, c := range metadata.Cameras {
+		offset, err := ParseTimestamp(c.Offset)
+		if err != nil {
+			return s, err
+		}
+		s.Doc.Cameras = append(s.Doc.Cameras, CameraSync{Name: c.Name, Offset: offset, Confidence: c.Confidence})
+	}
+	if metadata.LTC != nil {
+		s.Doc.LTC, err = metadata.LTC.track()
+		if err != nil {
+			return s, fmt.Errorf("Failed to read session timecode: %s", err)
+		}
+	}
+
+	err = s.loadSyncTakes(path.Join(dir, "sync_takes.csv"))
+	if err != nil {
+		return s, err
+	}
+	err = s.loadTakes(path.Join(dir, "takes.csv"))
+	return s, err
+}
+
+// Reads a csv file with a header row. Returns the rows after the header, and a function that looks up a column of a
+// row by name, which returns an empty string for missing columns.
+func readCSVTable(p string) ([][]string, func(row []string, name string) string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	// older sessions have fewer columns
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("%s is empty", path.Base(p))
+	}
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	return rows[1:], field, nil
+}
+
+// Loads the sync takes. Sessions recorded before sync takes were saved have no sync takes file.
+func (s *Session) loadSyncTakes(syncPath string) error {
+	rows, field, err := readCSVTable(syncPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		take := Take{Mark: Sync}
+		take.Start, err = ParseTimestamp(field(row, "take_start"))
+		if err != nil {
+			return err
+		}
+		take.End, err = ParseTimestamp(field(row, "take_end"))
+		if err != nil {
+			return err
+		}
+		rating, _ := strconv.Atoi(field(row, "take_rating"))
+		take.Rating = uint8(rating)
+		take.Tags = parseTags(strings.ReplaceAll(field(row, "take_tags"), ";", ","))
+		take.Note = field(row, "take_note")
+		if cameraTime := field(row, "take_camera_time"); cameraTime != "" {
+			take.CameraTime, err = ParseTimestamp(cameraTime)
+			if err != nil {
+				return err
+			}
+		}
+		take.Camera = field(row, "take_camera")
+		s.Doc.syncTakes = append(s.Doc.syncTakes, take)
+	}
+	return nil
+}
+
+// Reads the position of a take in the audio. Older sessions only have the synced timestamps, rounded to the
+// millisecond, which are converted back with the current sync.
+func (s *Session) loadTakeSpan(row []string, field func(row []string, name string) string) (TimeSpan, error) {
+	start, startErr := strconv.Atoi(field(row, "take_start_sample"))
+	end, endErr := strconv.Atoi(field(row, "take_end_sample"))
+	if startErr == nil && endErr == nil {
+		return TimeSpan{Start: samplesToDuration(sampleRate, start), End: samplesToDuration(sampleRate, end)}, nil
+	}
+
+	var t TimeSpan
+	synced, err := ParseTimestamp(field(row, "take_start"))
+	if err != nil {
+		return t, err
+	}
+	t.Start = s.Doc.UnsyncedTime(synced)
+	synced, err = ParseTimestamp(field(row, "take_end"))
+	if err != nil {
+		return t, err
+	}
+	t.End = s.Doc.UnsyncedTime(synced)
+	return t, nil
+}
+
+func (s *Session) loadTakes(takesPath string) error {
+	rows, field, err := readCSVTable(takesPath)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		take := Take{}
+		take.Mark, err = parseTakeMark(field(row, "take_mark"))
+		if err != nil {
+			return err
+		}
+		take.TimeSpan, err = s.loadTakeSpan(row, field)
+		if err != nil {
+			return err
+		}
+		rating, _ := strconv.Atoi(field(row, "take_rating"))
+		take.Rating = uint8(rating)
+		take.Tags = parseTags(strings.ReplaceAll(field(row, "take_tags"), ";", ","))
+		take.Note = field(row, "take_note")
+		take.Circled = field(row, "take_circled") == "true"
+		chunk, err := s.Doc.findChunk(field(row, "header"), field(row, "chunk_index"))
+		if err != nil {
+			return err
+		}
+		chunk.Takes = append(chunk.Takes, take)
+	}
+
+	if t, ok := s.Doc.firstSyncSpan(); ok && !s.Doc.SyncManual {
+		d := detectSyncPeak(s.ExtractAudio(t))
+		s.Doc.syncCandidates = append(s.Doc.syncCandidates, t.Start+samplesToDuration(sampleRate, d.Index))
+	}
+	return nil
+}
+
 func (s *Session) StartStreamingToDisk() (*wav.Encoder, error) {
 	dir, err := s.getSessionDir()
 	if err != nil {
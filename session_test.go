@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoadSession(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+
+	script := "# Intro\nchunk 1\n\nchunk 2\n# Outro\nchunk 3"
+	currentSession = Session{
+		Doc: parseDoc(script),
+	}
+	currentSession.deriveId()
+	currentSession.Audio = modulatedNoise(sampleRate * 5)
+	if err := currentSession.saveAudio(); err != nil {
+		t.Fatal(err)
+	}
+	currentSession.hasBeenSaved = true
+
+	currentSession.Doc.SyncOffset = 500 * time.Millisecond
+	currentSession.Doc.SyncDrift = 1.001
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 100 * time.Millisecond, End: 900 * time.Millisecond}, Mark: Sync},
+		{TimeSpan: TimeSpan{Start: 4 * time.Second, End: 4500 * time.Millisecond}, Mark: Sync, CameraTime: 3600 * time.Millisecond},
+		{TimeSpan: TimeSpan{Start: 4600 * time.Millisecond, End: 4900 * time.Millisecond}, Mark: Sync, Camera: "B"},
+	}
+	currentSession.Doc.Cameras = []CameraSync{{Name: "B", Offset: 4700 * time.Millisecond, Confidence: 0.5}}
+	currentSession.Doc.GetChunk(0).Takes = []Take{
+		{TimeSpan: TimeSpan{Start: samplesToDuration(sampleRate, 44123), End: 2 * time.Second}, Mark: Good, Rating: 4, Tags: []string{"happy read", "alt"}, Note: "mouth click, at 0:03", Circled: true},
+	}
+	currentSession.Doc.GetChunk(2).Takes = []Take{
+		{TimeSpan: TimeSpan{Start: 3 * time.Second, End: 3500 * time.Millisecond}, Mark: Bad},
+	}
+	saved := currentSession
+	if err := currentSession.FullSave(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := currentSession.getSessionDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, field, err := readCSVTable(path.Join(dir, "takes.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("Expected takes.csv to have only the 2 chunk takes, got %d rows", len(rows))
+	}
+	for _, row := range rows {
+		if field(row, "header") == "" {
+			t.Errorf("Every row of takes.csv should belong to a chunk, got %v", row)
+		}
+	}
+
+	loaded, err := loadSession(saved.Id, parseDoc(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Audio, saved.Audio) {
+		t.Errorf("Loaded audio differs from saved audio")
+	}
+	if loaded.Doc.SyncOffset != saved.Doc.SyncOffset || loaded.Doc.SyncDrift != saved.Doc.SyncDrift {
+		t.Errorf("Incorrect sync loaded: %s %v", loaded.Doc.SyncOffset, loaded.Doc.SyncDrift)
+	}
+	if !reflect.DeepEqual(loaded.Doc.Cameras, saved.Doc.Cameras) {
+		t.Errorf("Incorrect cameras loaded: %v", loaded.Doc.Cameras)
+	}
+	if !reflect.DeepEqual(loaded.Doc.syncTakes, saved.Doc.syncTakes) {
+		t.Errorf("Incorrect sync takes loaded: %v", loaded.Doc.syncTakes)
+	}
+	for i := 0; i < saved.Doc.CountChunks(); i++ {
+		expect := saved.Doc.GetChunk(i).Takes
+		got := loaded.Doc.GetChunk(i).Takes
+		if len(expect) != len(got) {
+			t.Fatalf("Chunk %d: expected %d takes, got %d", i, len(expect), len(got))
+		}
+		for j := range expect {
+			if !reflect.DeepEqual(got[j], expect[j]) {
+				t.Errorf("Chunk %d take %d: expected %+v, got %+v", i, j, expect[j], got[j])
+			}
+		}
+	}
+}
+
+func withinMillisecond(a, b time.Duration) bool {
+	d := a - b
+	return d > -time.Millisecond && d < time.Millisecond
+}
+
+func TestLoadTakeSpanWithoutSamples(t *testing.T) {
+	s := Session{}
+	s.Doc.SyncOffset = 500 * time.Millisecond
+	columns := map[string]string{"take_start": "00:00:01.000", "take_end": "00:00:02.250"}
+	field := func(row []string, name string) string { return columns[name] }
+	span, err := s.loadTakeSpan(nil, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if span.Start != 1500*time.Millisecond || span.End != 2750*time.Millisecond {
+		t.Errorf("Expected older sessions to be unsynced, got %v", span)
+	}
+}
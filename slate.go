@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	slateToneLength    = 200 * time.Millisecond
+	slateToneFrequency = 1000
+	// Recording kept in the slate's sync take on either side of the tone.
+	slateMargin = 500 * time.Millisecond
+	// How far from where it was played the slate tone can be heard, for it to be used as the sync peak.
+	maxSlateDeviation = 50 * time.Millisecond
+)
+
+// Whether to play the slate tone as soon as the session starts recording.
+var slateAtStart bool = false
+
+var isPlayingSlate bool = false
+
+// A short sine tone that starts abruptly, so it is easy to find in the camera's audio.
+func slateTone() []int32 {
+	samples := make([]int32, durationToSamples(sampleRate, slateToneLength))
+	fade := durationToSamples(sampleRate, 5*time.Millisecond)
+	for i := range samples {
+		v := math.Sin(2 * math.Pi * slateToneFrequency * float64(i) / sampleRate)
+		// only the end is faded, to avoid a click that could be mistaken for the start
+		if rest := len(samples) - i; rest < fade {
+			v *= float64(rest) / float64(fade)
+		}
+		samples[i] = int32(v * math.MaxInt32 / 2)
+	}
+	return samples
+}
+
+// Plays the slate tone, and adds a sync take around it once it has been recorded.
+func playSlate() {
+	if isPlayingSlate {
+		return
+	}
+	isPlayingSlate = true
+	defer func() {
+		isPlayingSlate = false
+	}()
+
+	sink, err := openPlaybackSink()
+	if err != nil {
+		setStatus("Failed to open audio output: %s", err)
+		return
+	}
+	defer sink.Close()
+
+	const bufSize = 1024
+	tone := slateTone()
+	playedAt := time.Now()
+	if s, ok := sink.(interface{ Latency() time.Duration }); ok {
+		playedAt = playedAt.Add(s.Latency())
+	}
+	for b := 0; b < len(tone); b += bufSize {
+		err = sink.Write(tone[b:clamp(b+bufSize, 0, len(tone))])
+		if err != nil {
+			setStatus("Failed to play slate: %s", err)
+			return
+		}
+	}
+
+	n, ok := recordingClock.SampleAt(playedAt)
+	if !ok {
+		setStatus("Slate was played before recording started")
+		return
+	}
+	played := samplesToDuration(sampleRate, n)
+	end := durationToSamples(sampleRate, played+slateToneLength+slateMargin)
+	for isRecording && len(currentSession.Audio) < end {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	recordHistory("Slate")
+	err = currentSession.addSlateTake(played)
+	if err != nil {
+		setStatus("%s", err)
+	} else {
+		setStatus("Slate played at %s", Timestamp(&played))
+	}
+	currentSession.FullSave()
+}
+
+// Plays the slate tone once the session has started recording.
+func playSlateAtStart() {
+	for {
+		if _, ok := recordingClock.SampleAt(time.Now()); ok {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	playSlate()
+}
+
+// Adds a sync take around the slate tone, which was played at the timestamp. If it is the first sync take, the sync
+// offset is set to where the tone was heard, or to where it was played if it can't be heard.
+func (s *Session) addSlateTake(played time.Duration) error {
+	start := played - slateMargin
+	if start < 0 {
+		start = 0
+	}
+	take := Take{
+		TimeSpan: TimeSpan{Start: start, End: played + slateToneLength + slateMargin},
+		Mark:     Sync,
+	}
+	s.Doc.syncTakes = append(s.Doc.syncTakes, take)
+	if s.Doc.firstSyncTake("") != len(s.Doc.syncTakes)-1 {
+		return nil
+	}
+
+	s.Doc.SyncOffset = played
+	s.Doc.SyncConfidence = 1
+	s.Doc.SyncManual = false
+	d := detectSyncPeak(s.ExtractAudio(take.TimeSpan))
+	heard := take.Start + samplesToDuration(sampleRate, d.Index)
+	if d.Confidence >= minSyncConfidence && heard-played <= maxSlateDeviation && played-heard <= maxSlateDeviation {
+		s.Doc.SyncOffset = heard
+		s.Doc.SyncConfidence = d.Confidence
+	}
+	s.Doc.syncCandidates = []time.Duration{s.Doc.SyncOffset}
+	return s.updateSyncDrift()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddSlateTake(t *testing.T) {
+	currentSession = Session{
+		Audio: make([]int32, sampleRate*4),
+	}
+	// the tone is heard a little after the clock says it was played
+	copy(currentSession.Audio[sampleRate:], slateTone())
+	if err := currentSession.addSlateTake(980 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.syncTakes) != 1 || currentSession.Doc.syncTakes[0].Mark != Sync {
+		t.Fatalf("Expected a sync take, got %v", currentSession.Doc.syncTakes)
+	}
+	if !withinMillisecond(currentSession.Doc.SyncOffset, time.Second) {
+		t.Errorf("Expected sync offset where the tone was heard, got %s", currentSession.Doc.SyncOffset)
+	}
+
+	// a later slate doesn't change the sync offset
+	if err := currentSession.addSlateTake(3 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.syncTakes) != 2 || !withinMillisecond(currentSession.Doc.SyncOffset, time.Second) {
+		t.Errorf("Expected sync offset to be kept, got %s", currentSession.Doc.SyncOffset)
+	}
+
+	// the tone wasn't picked up at all
+	currentSession = Session{
+		Audio: make([]int32, sampleRate*4),
+	}
+	if err := currentSession.addSlateTake(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if currentSession.Doc.SyncOffset != 2*time.Second {
+		t.Errorf("Expected sync offset where the tone was played, got %s", currentSession.Doc.SyncOffset)
+	}
+}
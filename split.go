@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// Length of the windows that audio is measured in when looking for silence.
+	silenceWindow = 10 * time.Millisecond
+	// Windows quieter than this fraction of the loudest window are considered silent.
+	silenceThreshold = 0.05
+	// Shortest pause that will be suggested as a split point.
+	minSplitSilence = 300 * time.Millisecond
+)
+
+// Points in the selected take where it will be split, in any order.
+var splitPoints []time.Duration
+
+// Finds the spans of silence in the samples that are at least minLength long.
+// The returned spans are sample indexes relative to the start of samples.
+func findSilences(samples []int32, minLength time.Duration) [][2]int {
+	window := durationToSamples(sampleRate, silenceWindow)
+	var levels []float64
+	loudest := 0.0
+	for w := 0; w+window <= len(samples); w += window {
+		sum := 0.0
+		for _, s := range samples[w : w+window] {
+			sum += float64(s) * float64(s)
+		}
+		rms := math.Sqrt(sum / float64(window))
+		levels = append(levels, rms)
+		loudest = math.Max(loudest, rms)
+	}
+
+	var silences [][2]int
+	minWindows := int(minLength / silenceWindow)
+	run := 0
+	for i := 0; i <= len(levels); i++ {
+		if i < len(levels) && levels[i] <= loudest*silenceThreshold {
+			run++
+			continue
+		}
+		// Silence at the very start or end of the samples is not a pause between chunks.
+		if run >= minWindows && run < i && i < len(levels) {
+			silences = append(silences, [2]int{(i - run) * window, i * window})
+		}
+		run = 0
+	}
+	return silences
+}
+
+// Suggests up to count points to split the samples at, in the middle of the longest pauses.
+// The points are sample indexes relative to the start of samples, in ascending order.
+func suggestSplitPoints(samples []int32, count int) []int {
+	silences := findSilences(samples, minSplitSilence)
+	sort.SliceStable(silences, func(i, j int) bool {
+		return silences[i][1]-silences[i][0] > silences[j][1]-silences[j][0]
+	})
+	if len(silences) > count {
+		silences = silences[:count]
+	}
+	var points []int
+	for _, s := range silences {
+		points = append(points, (s[0]+s[1])/2)
+	}
+	sort.Ints(points)
+	return points
+}
+
+// Splits the take at the points, returning one take for each part. Every part keeps the mark,
+// rating and tags of the original take, only the first part keeps the note and circle. Points outside of the take are ignored.
+func splitTake(take Take, points []time.Duration) []Take {
+	sorted := append([]time.Duration(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var parts []Take
+	part := take
+	for _, p := range sorted {
+		if p <= part.Start || p >= take.End {
+			continue
+		}
+		part.End = p
+		parts = append(parts, part)
+		part = take
+		part.Start = p
+		part.Note = ""
+		part.Circled = false
+		part.Tags = append([]string(nil), take.Tags...)
+	}
+	part.End = take.End
+	return append(parts, part)
+}
+
+// Splits the selected take at the split points, and assigns each part after the first to the
+// chunks following the selected chunk.
+func applySplit() error {
+	if viewingSyncTakes {
+		return errors.New("Sync takes can't be split")
+	}
+	chunk := currentSession.Doc.GetChunk(int(selectedChunk))
+	if selectedTake < 0 || selectedTake >= len(chunk.Takes) {
+		return errors.New("No take selected")
+	}
+	parts := splitTake(chunk.Takes[selectedTake], splitPoints)
+	if len(parts) < 2 {
+		return errors.New("No split points inside the selected take")
+	}
+	if int(selectedChunk)+len(parts) > currentSession.Doc.CountChunks() {
+		return fmt.Errorf("Take split into %d parts, but there are not enough chunks after the selected chunk", len(parts))
+	}
+
+	recordHistory("Split Take")
+	chunk.Takes[selectedTake] = parts[0]
+	for i, part := range parts[1:] {
+		c := currentSession.Doc.GetChunk(int(selectedChunk) + 1 + i)
+		c.Takes = append(c.Takes, part)
+	}
+	splitPoints = nil
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Creates audio with a tone for each of the durations, separated by pauses of the given lengths.
+func speechWithPauses(tones []time.Duration, pauses []time.Duration) []int32 {
+	var samples []int32
+	for i, t := range tones {
+		samples = append(samples, sineWave(220, durationToSamples(sampleRate, t))...)
+		if i < len(pauses) {
+			samples = append(samples, make([]int32, durationToSamples(sampleRate, pauses[i]))...)
+		}
+	}
+	return samples
+}
+
+func TestSuggestSplitPoints(t *testing.T) {
+	samples := speechWithPauses(
+		[]time.Duration{time.Second, time.Second, time.Second, time.Second},
+		[]time.Duration{800 * time.Millisecond, 100 * time.Millisecond, 500 * time.Millisecond},
+	)
+
+	points := suggestSplitPoints(samples, 5)
+	if len(points) != 2 {
+		t.Fatalf("Expected 2 pauses long enough to split at, got %d", len(points))
+	}
+	expect := []time.Duration{1400 * time.Millisecond, 4150 * time.Millisecond}
+	for i, p := range points {
+		d := samplesToDuration(sampleRate, p)
+		if d < expect[i]-50*time.Millisecond || d > expect[i]+50*time.Millisecond {
+			t.Errorf("Expected split point %d around %s, got %s", i, expect[i], d)
+		}
+	}
+
+	points = suggestSplitPoints(samples, 1)
+	if len(points) != 1 || samplesToDuration(sampleRate, points[0]) > 2*time.Second {
+		t.Errorf("Expected only the longest pause to be suggested, got %v", points)
+	}
+}
+
+func TestSplitTake(t *testing.T) {
+	take := Take{
+		TimeSpan: TimeSpan{Start: time.Second, End: 10 * time.Second},
+		Mark:     Good,
+		Note:     "read three chunks",
+	}
+	parts := splitTake(take, []time.Duration{6 * time.Second, 3 * time.Second, 12 * time.Second})
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+	expect := []TimeSpan{
+		{Start: time.Second, End: 3 * time.Second},
+		{Start: 3 * time.Second, End: 6 * time.Second},
+		{Start: 6 * time.Second, End: 10 * time.Second},
+	}
+	for i, p := range parts {
+		if p.TimeSpan != expect[i] {
+			t.Errorf("Part %d: expected %v, got %v", i, expect[i], p.TimeSpan)
+		}
+		if p.Mark != Good {
+			t.Errorf("Part %d: expected mark to be kept", i)
+		}
+	}
+	if parts[0].Note == "" || parts[1].Note != "" {
+		t.Errorf("Expected only the first part to keep the note")
+	}
+}
+
+func TestSplitPointsCancelTransfer(t *testing.T) {
+	currentSession = Session{
+		Doc: parseDoc("# Test\nchunk 1\n\nchunk 2\n\nchunk 3"),
+		Audio: speechWithPauses(
+			[]time.Duration{time.Second, time.Second},
+			[]time.Duration{800 * time.Millisecond},
+		),
+	}
+	currentSession.Doc.GetChunk(0).Takes = []Take{{TimeSpan: TimeSpan{End: samplesToDuration(sampleRate, len(currentSession.Audio))}}}
+	selectedChunk, selectedTake, viewingSyncTakes = 0, 0, false
+	defer func() { splitPoints, pendingTransfer = nil, nil }()
+
+	beginTransfer(false)
+	keybindSuggestSplitPoints()
+	if len(splitPoints) == 0 {
+		t.Fatal("Expected a split point")
+	}
+	if pendingTransfer != nil {
+		t.Errorf("Expected suggesting split points to cancel the pending transfer")
+	}
+	beginTransfer(true)
+	if splitPoints != nil {
+		t.Errorf("Expected starting a transfer to clear the split points")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// Length of the blocks that audio is measured in when looking for transients, in samples.
+	transientBlock = sampleRate / 1000
+	// Number of blocks before a block that its rise in level is measured against.
+	transientBackground = 50
+	// Transients closer together than this many blocks are treated as the same transient.
+	transientSeparation = 100
+	// Number of runner-up transients reported.
+	maxRunnersUp = 3
+	// Sync takes where the sync peak is less clear than this are rejected.
+	minSyncConfidence = 0.25
+)
+
+type syncDetection struct {
+	// Sample index of the onset of the sync peak, relative to the start of the analysed audio.
+	Index int
+	// How much the sync peak stands out from the runner-up transients, from 0 (not at all) to 1.
+	Confidence float64
+	// Sample indexes of the onsets of the next strongest transients, strongest first.
+	RunnersUp []int
+}
+
+type transient struct {
+	onset    int
+	strength float64
+}
+
+// Finds the onset of the sharpest, loudest transient in the samples, like a clap or clapperboard.
+// Transients are measured by how far the absolute amplitude rises above the level just before them,
+// so loud but sustained sounds and negative excursions are handled correctly.
+func detectSyncPeak(samples []int32) syncDetection {
+	blocks := len(samples) / transientBlock
+	if blocks == 0 {
+		return syncDetection{}
+	}
+	peaks := make([]float64, blocks)
+	for b := range peaks {
+		for _, s := range samples[b*transientBlock : (b+1)*transientBlock] {
+			peaks[b] = math.Max(peaks[b], math.Abs(float64(s)))
+		}
+	}
+
+	// Blocks are only scored once there is a full background before them, otherwise a take that starts during
+	// sound would have a transient at its start.
+	strengths := make([]float64, blocks)
+	background := 0.0
+	for b := range peaks {
+		if b > 0 {
+			background += peaks[b-1]
+		}
+		if b > transientBackground {
+			background -= peaks[b-1-transientBackground]
+		}
+		if b >= transientBackground {
+			strengths[b] = math.Max(0, peaks[b]-background/transientBackground)
+		}
+	}
+
+	// keep only the strongest block of each transient
+	var transients []transient
+	for b, strength := range strengths {
+		if strength == 0 {
+			continue
+		}
+		isMax := true
+		for o := clamp(b-transientSeparation, 0, blocks); o < clamp(b+transientSeparation+1, 0, blocks); o++ {
+			if strengths[o] > strength || (strengths[o] == strength && o < b) {
+				isMax = false
+				break
+			}
+		}
+		if isMax {
+			transients = append(transients, transient{onset: transientOnset(samples, b, peaks[b]), strength: strength})
+		}
+	}
+	if len(transients) == 0 {
+		return syncDetection{}
+	}
+	sort.SliceStable(transients, func(i, j int) bool {
+		return transients[i].strength > transients[j].strength
+	})
+
+	d := syncDetection{
+		Index:      transients[0].onset,
+		Confidence: 1,
+	}
+	if len(transients) > 1 {
+		d.Confidence = 1 - transients[1].strength/transients[0].strength
+	}
+	for _, t := range transients[1:] {
+		if len(d.RunnersUp) == maxRunnersUp {
+			break
+		}
+		d.RunnersUp = append(d.RunnersUp, t.onset)
+	}
+	return d
+}
+
+// Finds the first sample of the transient peaking in the block, where the amplitude first reaches half its peak.
+func transientOnset(samples []int32, block int, peak float64) int {
+	start := clamp(block-2, 0, block) * transientBlock
+	for i := start; i < (block+1)*transientBlock; i++ {
+		if math.Abs(float64(samples[i])) >= peak/2 {
+			return i
+		}
+	}
+	return block * transientBlock
+}
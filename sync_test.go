@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Adds a clap like burst of noise to the samples, starting at the index.
+func addClap(samples []int32, at int, amplitude float64, negative bool) {
+	for i := 0; i < sampleRate/100 && at+i < len(samples); i++ {
+		v := amplitude * math.Exp(-float64(i)/100) * math.Sin(float64(i)*1.3)
+		if negative {
+			v = -math.Abs(v)
+		}
+		samples[at+i] += int32(v)
+	}
+}
+
+func TestDetectSyncPeak(t *testing.T) {
+	t.Run("negative clap", func(t *testing.T) {
+		samples := make([]int32, sampleRate*2)
+		addClap(samples, 30000, math.MaxInt32/2, true)
+		d := detectSyncPeak(samples)
+		if d.Index < 30000 || d.Index > 30010 {
+			t.Errorf("Expected onset at 30000, got %d", d.Index)
+		}
+		if d.Confidence != 1 {
+			t.Errorf("Expected full confidence with a single transient, got %v", d.Confidence)
+		}
+	})
+
+	t.Run("clap after sustained noise", func(t *testing.T) {
+		// a loud sustained tone is louder than the clap, but does not start suddenly
+		samples := sineWave(200, sampleRate*2)
+		for i := 0; i < sampleRate/2; i++ {
+			samples[i] = int32(float64(samples[i]) * float64(i) / float64(sampleRate/2))
+		}
+		for i := sampleRate; i < len(samples); i++ {
+			samples[i] = 0
+		}
+		addClap(samples, sampleRate+20000, math.MaxInt32/3, false)
+		d := detectSyncPeak(samples)
+		if d.Index < sampleRate+20000 || d.Index > sampleRate+20010 {
+			t.Errorf("Expected onset at %d, got %d", sampleRate+20000, d.Index)
+		}
+	})
+
+	t.Run("take starting during noise", func(t *testing.T) {
+		// the take is cut in the middle of loud room noise, which should not count as a transient at its start
+		r := rand.New(rand.NewSource(1))
+		samples := make([]int32, sampleRate*2)
+		for i := range samples {
+			samples[i] = int32((r.Float64()*2 - 1) * 0.4 * math.MaxInt32)
+		}
+		addClap(samples, sampleRate, math.MaxInt32/3, false)
+		d := detectSyncPeak(samples)
+		// loud noise can reach half the peak in the blocks searched for the onset, so the onset is less precise
+		if d.Index < sampleRate-3*transientBlock || d.Index > sampleRate+10 {
+			t.Errorf("Expected onset at %d, got %d", sampleRate, d.Index)
+		}
+	})
+
+	t.Run("ambiguous claps", func(t *testing.T) {
+		samples := make([]int32, sampleRate*2)
+		addClap(samples, 10000, math.MaxInt32/2, false)
+		addClap(samples, 50000, math.MaxInt32/2*0.95, false)
+		addClap(samples, 70000, math.MaxInt32/8, false)
+		d := detectSyncPeak(samples)
+		if d.Confidence >= minSyncConfidence {
+			t.Errorf("Expected low confidence with two similar claps, got %v", d.Confidence)
+		}
+		if len(d.RunnersUp) != 2 || d.RunnersUp[0] < 50000 || d.RunnersUp[0] > 50010 {
+			t.Errorf("Expected runners up to be reported, got %v", d.RunnersUp)
+		}
+	})
+}
+
+func TestUpdateSyncDrift(t *testing.T) {
+	currentSession = Session{
+		Audio: make([]int32, sampleRate*12),
+	}
+	addClap(currentSession.Audio, sampleRate, math.MaxInt32/2, false)
+	addClap(currentSession.Audio, sampleRate*11, math.MaxInt32/2, false)
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 500 * time.Millisecond, End: 2 * time.Second}, Mark: Sync},
+		{TimeSpan: TimeSpan{Start: 10500 * time.Millisecond, End: 12 * time.Second}, Mark: Sync, CameraTime: 10010 * time.Millisecond},
+	}
+
+	if err := currentSession.updateSyncOffset(); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(currentSession.Doc.SyncDrift-1.001) > 0.0001 {
+		t.Errorf("Expected drift of 1.001, got %v", currentSession.Doc.SyncDrift)
+	}
+	synced := currentSession.Doc.SyncedTime(11 * time.Second)
+	if synced < 10009*time.Millisecond || synced > 10011*time.Millisecond {
+		t.Errorf("Expected second sync peak at the camera time, got %s", synced)
+	}
+
+	currentSession.Doc.syncTakes[1].CameraTime = 5 * time.Second
+	if err := currentSession.updateSyncDrift(); err == nil {
+		t.Errorf("Expected an unlikely drift to be rejected")
+	}
+	if currentSession.Doc.SyncDrift != 0 {
+		t.Errorf("Expected drift correction to be disabled, got %v", currentSession.Doc.SyncDrift)
+	}
+}
+
+func TestSetSyncOffset(t *testing.T) {
+	currentSession = Session{
+		Audio: make([]int32, sampleRate*2),
+	}
+	addClap(currentSession.Audio, sampleRate, math.MaxInt32/2, false)
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 500 * time.Millisecond, End: 1500 * time.Millisecond}, Mark: Sync},
+	}
+
+	if err := currentSession.setSyncOffset(1200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if currentSession.Doc.SyncOffset != 1200*time.Millisecond || !currentSession.Doc.SyncManual {
+		t.Errorf("Expected manual sync offset at 1.2s, got %s", currentSession.Doc.SyncOffset)
+	}
+	currentSession.setSyncOffset(5 * time.Second)
+	if currentSession.Doc.SyncOffset != 2*time.Second {
+		t.Errorf("Expected sync offset to be kept within the recording, got %s", currentSession.Doc.SyncOffset)
+	}
+
+	if err := currentSession.updateSyncOffset(); err != nil {
+		t.Fatal(err)
+	}
+	if currentSession.Doc.SyncManual {
+		t.Errorf("Expected detection to replace the manual sync offset")
+	}
+	if d := currentSession.Doc.SyncOffset - time.Second; d < 0 || d > time.Millisecond {
+		t.Errorf("Expected detected sync offset at 1s, got %s", currentSession.Doc.SyncOffset)
+	}
+}
+
+func TestUpdateCameraSyncs(t *testing.T) {
+	currentSession = Session{
+		Audio: make([]int32, sampleRate*6),
+	}
+	addClap(currentSession.Audio, sampleRate, math.MaxInt32/2, false)
+	addClap(currentSession.Audio, sampleRate*4, math.MaxInt32/2, false)
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 3500 * time.Millisecond, End: 5 * time.Second}, Mark: Sync, Camera: "B"},
+		{TimeSpan: TimeSpan{Start: 500 * time.Millisecond, End: 2 * time.Second}, Mark: Sync},
+	}
+
+	if err := currentSession.updateSyncOffset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := currentSession.updateCameraSyncs(); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.Cameras) != 1 || currentSession.Doc.Cameras[0].Name != "B" {
+		t.Fatalf("Expected camera B, got %v", currentSession.Doc.Cameras)
+	}
+
+	take := 5 * time.Second
+	main, _ := currentSession.Doc.SyncedTimeFor("", take)
+	b, _ := currentSession.Doc.SyncedTimeFor("B", take)
+	if !withinMillisecond(main, 4*time.Second) {
+		t.Errorf("Expected take 4s after the main sync peak, got %s", main)
+	}
+	if !withinMillisecond(b, time.Second) {
+		t.Errorf("Expected take 1s after the sync peak of camera B, got %s", b)
+	}
+	if _, ok := currentSession.Doc.SyncedTimeFor("C", take); ok {
+		t.Errorf("Expected no timing for an unknown camera")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// The amount take boundaries are moved by when nudged.
+type nudgeUnit uint8
+
+const (
+	nudgeSample nudgeUnit = iota
+	nudgeFrame
+	nudge10ms
+)
+
+var nudgeStep nudgeUnit = nudge10ms
+
+// Frame rate of the video the voice over is for, used when nudging by frame.
+var videoFrameRate float64 = 30
+
+// How far from a take boundary a zero crossing will be searched for.
+const zeroCrossingSearch = 10 * time.Millisecond
+
+func (u nudgeUnit) String() string {
+	switch u {
+	case nudgeSample:
+		return "sample"
+	case nudgeFrame:
+		return fmt.Sprintf("frame (%g fps)", videoFrameRate)
+	case nudge10ms:
+		return "10ms"
+	}
+	return "unknown"
+}
+
+func (u nudgeUnit) next() nudgeUnit {
+	return (u + 1) % (nudge10ms + 1)
+}
+
+// Moves the timestamp by the number of steps of the unit. Negative steps move it earlier.
+func nudgeTimestamp(t time.Duration, steps int, unit nudgeUnit) time.Duration {
+	switch unit {
+	case nudgeSample:
+		return samplesToDuration(sampleRate, durationToSamples(sampleRate, t)+steps)
+	case nudgeFrame:
+		return t + time.Duration(float64(steps)*float64(time.Second)/videoFrameRate)
+	default:
+		return t + time.Duration(steps)*10*time.Millisecond
+	}
+}
+
+// Moves the start (or end, if end is true) of the take, keeping it within the recorded audio
+// and keeping the start before the end.
+func nudgeTake(take *Take, end bool, steps int, unit nudgeUnit) {
+	recorded := samplesToDuration(sampleRate, len(currentSession.Audio))
+	if end {
+		take.End = nudgeTimestamp(take.End, steps, unit)
+		if take.End > recorded {
+			take.End = recorded
+		}
+		if take.End <= take.Start {
+			take.End = nudgeTimestamp(take.Start, 1, nudgeSample)
+		}
+	} else {
+		take.Start = nudgeTimestamp(take.Start, steps, unit)
+		if take.Start < 0 {
+			take.Start = 0
+		}
+		if take.Start >= take.End {
+			take.Start = nudgeTimestamp(take.End, -1, nudgeSample)
+		}
+	}
+}
+
+// Returns the index of the zero crossing nearest to idx, no further than maxDist samples away.
+// Returns idx if there is no zero crossing in range.
+func nearestZeroCrossing(samples []int32, idx int, maxDist int) int {
+	isCrossing := func(i int) bool {
+		if i <= 0 || i >= len(samples) {
+			return false
+		}
+		return samples[i] == 0 || (samples[i-1] < 0) != (samples[i] < 0)
+	}
+	for d := 0; d <= maxDist; d++ {
+		if isCrossing(idx - d) {
+			return idx - d
+		}
+		if isCrossing(idx + d) {
+			return idx + d
+		}
+	}
+	return idx
+}
+
+// Moves both boundaries of the take to the nearest zero crossings, to avoid clicks when cutting.
+func snapTakeToZeroCrossings(take *Take) {
+	maxDist := durationToSamples(sampleRate, zeroCrossingSearch)
+	start := nearestZeroCrossing(currentSession.Audio, durationToSamples(sampleRate, take.Start), maxDist)
+	end := nearestZeroCrossing(currentSession.Audio, durationToSamples(sampleRate, take.End), maxDist)
+	if start < end {
+		take.Start = samplesToDuration(sampleRate, start)
+		take.End = samplesToDuration(sampleRate, end)
+	}
+}
+
+// Redetects the sync that depends on a sync take after its boundaries were edited. Edits to other takes leave the sync
+// alone.
+func resyncEditedTake(take *Take) error {
+	doc := &currentSession.Doc
+	for i := range doc.syncTakes {
+		if &doc.syncTakes[i] != take {
+			continue
+		}
+		if take.Camera != "" {
+			if i == doc.firstSyncTake(take.Camera) {
+				return currentSession.updateCameraSyncs()
+			}
+			return nil
+		}
+		if i == doc.firstSyncTake("") {
+			return currentSession.updateSyncOffset()
+		}
+		if take.CameraTime != 0 {
+			return currentSession.updateSyncDrift()
+		}
+	}
+	return nil
+}
+
+func deleteTake(takes []Take, idx int) []Take {
+	return append(takes[:idx:idx], takes[idx+1:]...)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNudgeTimestamp(t *testing.T) {
+	start := samplesToDuration(sampleRate, 1000)
+	if n := durationToSamples(sampleRate, nudgeTimestamp(start, 1, nudgeSample)); n != 1001 {
+		t.Errorf("Expected nudge by 1 sample to land on sample 1001, got %d", n)
+	}
+	if n := durationToSamples(sampleRate, nudgeTimestamp(start, -3, nudgeSample)); n != 997 {
+		t.Errorf("Expected nudge by -3 samples to land on sample 997, got %d", n)
+	}
+	if d := nudgeTimestamp(time.Second, 2, nudge10ms); d != time.Second+20*time.Millisecond {
+		t.Errorf("Incorrect nudge by 10ms: %s", d)
+	}
+
+	defer func(fps float64) { videoFrameRate = fps }(videoFrameRate)
+	videoFrameRate = 25
+	if d := nudgeTimestamp(time.Second, -1, nudgeFrame); d != time.Second-40*time.Millisecond {
+		t.Errorf("Incorrect nudge by frame: %s", d)
+	}
+}
+
+func TestNearestZeroCrossing(t *testing.T) {
+	samples := []int32{5, 4, 3, 2, 1, -1, -2, -3, -4, -5, -6, 7}
+	if i := nearestZeroCrossing(samples, 3, 5); i != 5 {
+		t.Errorf("Expected zero crossing at 5, got %d", i)
+	}
+	if i := nearestZeroCrossing(samples, 9, 5); i != 11 {
+		t.Errorf("Expected zero crossing at 11, got %d", i)
+	}
+	if i := nearestZeroCrossing(samples, 1, 2); i != 1 {
+		t.Errorf("Expected index to be unchanged when no zero crossing is in range, got %d", i)
+	}
+}
+
+func TestDeleteTake(t *testing.T) {
+	takes := []Take{{Mark: Good}, {Mark: Bad}, {Mark: Sync}}
+	remaining := deleteTake(takes, 1)
+	if len(remaining) != 2 || remaining[0].Mark != Good || remaining[1].Mark != Sync {
+		t.Errorf("Incorrect takes after deletion: %v", remaining)
+	}
+	if takes[1].Mark != Bad {
+		t.Errorf("Expected original takes to be left untouched")
+	}
+}
+
+func TestResyncEditedTake(t *testing.T) {
+	defer func(fps float64) { videoFrameRate = fps }(videoFrameRate)
+	videoFrameRate = 25
+	currentSession = Session{
+		Doc:   parseDoc("# Intro\nchunk 1"),
+		Audio: make([]int32, sampleRate*4),
+	}
+	addClap(currentSession.Audio, sampleRate, math.MaxInt32/2, false)
+	addClap(currentSession.Audio, sampleRate*3, math.MaxInt32/2, false)
+	currentSession.Doc.syncTakes = []Take{
+		{TimeSpan: TimeSpan{Start: 500 * time.Millisecond, End: 1500 * time.Millisecond}, Mark: Sync},
+		{TimeSpan: TimeSpan{Start: 2500 * time.Millisecond, End: 3500 * time.Millisecond}, Mark: Sync, Camera: "B"},
+	}
+	currentSession.Doc.GetChunk(0).Takes = []Take{{TimeSpan: TimeSpan{Start: 0, End: time.Second}, Mark: Good}}
+	near := func(got, want time.Duration) bool {
+		return got >= want-time.Millisecond && got <= want+time.Millisecond
+	}
+
+	// editing a chunk take leaves the sync alone
+	take := &currentSession.Doc.GetChunk(0).Takes[0]
+	nudgeTake(take, true, 10, nudgeFrame)
+	if err := resyncEditedTake(take); err != nil || currentSession.Doc.SyncOffset != 0 {
+		t.Errorf("Expected no sync for a chunk take, got %s %v", currentSession.Doc.SyncOffset, err)
+	}
+
+	// moving the main sync take over the second clap by frames moves the sync offset with it
+	take = &currentSession.Doc.syncTakes[0]
+	nudgeTake(take, true, 50, nudgeFrame)
+	nudgeTake(take, false, 50, nudgeFrame)
+	if err := resyncEditedTake(take); err != nil {
+		t.Fatal(err)
+	}
+	if !near(currentSession.Doc.SyncOffset, 3*time.Second) {
+		t.Errorf("Expected the sync offset to follow the nudged sync take to 3s, got %s", currentSession.Doc.SyncOffset)
+	}
+
+	// snapping a camera's sync take redetects that camera
+	currentSession.Doc.Cameras = nil
+	take = &currentSession.Doc.syncTakes[1]
+	snapTakeToZeroCrossings(take)
+	if err := resyncEditedTake(take); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.Cameras) != 1 || !near(currentSession.Doc.Cameras[0].Offset, 3*time.Second) {
+		t.Errorf("Expected camera B to be synced at 3s after snapping, got %+v", currentSession.Doc.Cameras)
+	}
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"image"
+	"strings"
 	"sync"
 
 	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/mouse"
 
 	"github.com/mum4k/termdash/private/canvas"
 	"github.com/mum4k/termdash/private/canvas/buffer"
@@ -15,6 +17,11 @@ import (
 
 type TakeListWidget struct {
 	mu sync.Mutex
+
+	// Index of the first take that fits in the widget
+	scroll int
+	// Number of rows above the first take
+	headerRows int
 }
 
 func (w *TakeListWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) error {
@@ -28,7 +35,27 @@ func (w *TakeListWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) error {
 
 	width := cvs.Area().Dx()
 
-	for i, Take := range currentSession.Doc.GetChunk(int(selectedChunk)).Takes {
+	w.headerRows = 0
+	if viewingSyncTakes {
+		cells := buffer.NewCells("Sync Takes", cell.FgColor(SYNC_COLOR), cell.Bold())
+		DrawCells(cvs, cells, cur.X, cur.Y)
+		cur.Y += 1
+		w.headerRows = 1
+	}
+
+	// keep the selected take in view
+	rows := cvs.Area().Dy() - w.headerRows
+	if selectedTake >= 0 && selectedTake < w.scroll {
+		w.scroll = selectedTake
+	} else if rows > 0 && selectedTake >= w.scroll+rows {
+		w.scroll = selectedTake - rows + 1
+	}
+	w.scroll = clamp(w.scroll, 0, len(*selectedTakes()))
+
+	for i, Take := range *selectedTakes() {
+		if i < w.scroll {
+			continue
+		}
 		color := cell.ColorWhite
 		symbolRune := ' '
 
@@ -38,6 +65,9 @@ func (w *TakeListWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) error {
 		} else if Take.Mark == Bad {
 			color = BAD_COLOR
 			symbolRune = '✗'
+		} else if Take.Mark == Sync {
+			color = SYNC_COLOR
+			symbolRune = 'S'
 		}
 
 		symbol := buffer.NewCell(symbolRune, cell.FgColor(color))
@@ -46,7 +76,38 @@ func (w *TakeListWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) error {
 			color = SELECT_COLOR
 		}
 
-		cells := buffer.NewCells(fmt.Sprintf("Take %d", i), cell.FgColor(color))
+		label := fmt.Sprintf("Take %d", i)
+		if Take.Circled {
+			label += " ◉"
+		}
+		if Take.Camera != "" {
+			label += " cam " + Take.Camera
+		}
+		if isComparing && i == comparingTake {
+			label += " ▶"
+		}
+		cells := buffer.NewCells(label, cell.FgColor(color))
+		start := Take.Start
+		if Take.Mark != Sync {
+			start = currentSession.Doc.SyncedTime(start)
+		}
+		timing := fmt.Sprintf(" %s %.1fs", Timestamp(&start), Take.Duration().Seconds())
+		if tc := currentSession.Doc.LTC.TimecodeAt(Take.Start); tc != "" {
+			timing += " tc " + tc
+		}
+		if Take.CameraTime != 0 {
+			timing += fmt.Sprintf(" camera %s", Timestamp(&Take.CameraTime))
+		}
+		cells = append(cells, buffer.NewCells(timing, cell.FgColor(METADATA_COLOR))...)
+		if Take.Rating > 0 {
+			cells = append(cells, buffer.NewCells(" "+strings.Repeat("★", int(Take.Rating)), cell.FgColor(SYNC_OFFSET_COLOR))...)
+		}
+		if len(Take.Tags) > 0 {
+			cells = append(cells, buffer.NewCells(" ["+strings.Join(Take.Tags, ", ")+"]", cell.FgColor(SYNC_COLOR))...)
+		}
+		if Take.Note != "" {
+			cells = append(cells, buffer.NewCells(" "+Take.Note, cell.FgColor(METADATA_COLOR))...)
+		}
 
 		header := []*buffer.Cell{
 			buffer.NewCell('[', cell.FgColor(cell.ColorWhite)),
@@ -54,6 +115,11 @@ func (w *TakeListWidget) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) error {
 			buffer.NewCell(']', cell.FgColor(cell.ColorWhite)),
 		}
 
+		if i == selectedTake && takeListFocused {
+			cvs.SetCell(cur, '>', cell.FgColor(SELECT_COLOR))
+		}
+		cur.X += 1
+
 		for _, cell := range header {
 			cvs.SetCell(cur, cell.Rune, cell.Opts)
 			cur.X += 1
@@ -85,6 +151,17 @@ func (w *TakeListWidget) Mouse(m *terminalapi.Mouse) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if m.Button == mouse.ButtonLeft {
+		i := w.scroll + m.Position.Y - w.headerRows
+		if i >= 0 && i < len(*selectedTakes()) {
+			selectTake(i)
+		}
+	} else if m.Button == mouse.ButtonWheelDown {
+		keybindNextTake()
+	} else if m.Button == mouse.ButtonWheelUp {
+		keybindPreviousTake()
+	}
+
 	return nil
 }
 
@@ -92,5 +169,7 @@ func (w *TakeListWidget) Options() widgetapi.Options {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	return widgetapi.Options{}
+	return widgetapi.Options{
+		WantMouse: widgetapi.MouseScopeWidget,
+	}
 }
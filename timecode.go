@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A SMPTE timecode frame rate.
+type frameRate struct {
+	name string
+	// The frame rate is num/den frames per second.
+	num, den  int64
+	dropFrame bool
+}
+
+var frameRates = []frameRate{
+	{name: "23.976", num: 24000, den: 1001},
+	{name: "24", num: 24, den: 1},
+	{name: "25", num: 25, den: 1},
+	{name: "29.97df", num: 30000, den: 1001, dropFrame: true},
+	{name: "29.97ndf", num: 30000, den: 1001},
+	{name: "30", num: 30, den: 1},
+	{name: "50", num: 50, den: 1},
+	{name: "60", num: 60, den: 1},
+}
+
+func parseFrameRate(s string) (frameRate, error) {
+	var names []string
+	for _, r := range frameRates {
+		if strings.EqualFold(s, r.name) {
+			return r, nil
+		}
+		names = append(names, r.name)
+	}
+	return frameRate{}, fmt.Errorf("Unsupported timecode frame rate %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+func (r frameRate) String() string {
+	return r.name
+}
+
+func (r frameRate) fps() float64 {
+	return float64(r.num) / float64(r.den)
+}
+
+// The number of frame labels in a second of timecode.
+func (r frameRate) timebase() int {
+	return int((r.num + r.den/2) / r.den)
+}
+
+// Number of whole frames that have passed after the duration.
+func (r frameRate) durationToFrames(d time.Duration) int {
+	frames := int64(d) * r.num / (r.den * int64(time.Second))
+	if d < 0 && int64(d)*r.num%(r.den*int64(time.Second)) != 0 {
+		frames--
+	}
+	return int(frames)
+}
+
+// The time at which the frame starts, rounded up to the nanosecond so it converts back to the same frame.
+func (r frameRate) framesToDuration(frames int) time.Duration {
+	n := int64(frames) * r.den * int64(time.Second)
+	if n > 0 {
+		n += r.num - 1
+	}
+	return time.Duration(n / r.num)
+}
+
+// Number of frames in the 24 hours of timecode.
+func (r frameRate) framesPerDay() int {
+	day := r.timebase() * 60 * 60 * 24
+	if r.dropFrame {
+		// 2 frame labels are dropped every minute, except every 10th minute
+		day -= 2 * 9 * 6 * 24
+	}
+	return day
+}
+
+// Formats the frame count as a timecode, wrapping around at 24 hours. Drop frame timecodes
+// separate the frames with a semicolon.
+func (r frameRate) timecode(frames int) string {
+	base := r.timebase()
+	day := r.framesPerDay()
+	frames %= day
+	if frames < 0 {
+		frames += day
+	}
+	sep := ":"
+	if r.dropFrame {
+		perMinute := base*60 - 2
+		perTenMinutes := base*60*10 - 2*9
+		tens, rem := frames/perTenMinutes, frames%perTenMinutes
+		frames += 2 * 9 * tens
+		if rem >= 2 {
+			frames += 2 * ((rem - 2) / perMinute)
+		}
+		sep = ";"
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d",
+		frames/(base*3600),
+		frames/(base*60)%60,
+		frames/base%60,
+		sep,
+		frames%base,
+	)
+}
+
+// Parses a timecode in the format written by timecode, returning the frame count.
+func (r frameRate) parseTimecode(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.FieldsFunc(s, func(c rune) bool { return c == ':' || c == ';' || c == '.' })
+	if len(parts) != 4 {
+		return 0, fmt.Errorf("Invalid timecode: %s", s)
+	}
+	var n [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("Invalid timecode: %s", s)
+		}
+		n[i] = v
+	}
+	frames, err := r.frameCount(n[0], n[1], n[2], n[3])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", err, s)
+	}
+	return frames, nil
+}
+
+// Counts the frames up to the timecode with the given fields.
+func (r frameRate) frameCount(h, m, sec, f int) (int, error) {
+	base := r.timebase()
+	if m >= 60 || sec >= 60 || f >= base {
+		return 0, errors.New("Invalid timecode")
+	}
+	frames := ((h*60+m)*60+sec)*base + f
+	if r.dropFrame {
+		if sec == 0 && f < 2 && m%10 != 0 {
+			return 0, errors.New("Invalid drop frame timecode")
+		}
+		minutes := h*60 + m
+		frames -= 2 * (minutes - minutes/10)
+	}
+	return frames, nil
+}
+
+// Frame rate that times are written as timecodes with, or nil to write them as timestamps.
+var timecodeRate *frameRate
+
+// Timecode of the synced timeline's zero, which is the sync peak, or the start of the recording if the session is
+// not synced.
+var startTimecode int
+
+// Formats a synced time as a timecode if a timecode frame rate is set, otherwise as a timestamp.
+func formatSyncedTime(t time.Duration) string {
+	if timecodeRate == nil {
+		return Timestamp(&t)
+	}
+	return timecodeRate.timecode(startTimecode + timecodeRate.durationToFrames(t))
+}
+
+// Sets the frame rate and start timecode that times are written as timecodes with.
+func setTimecodeFormat(rate string, start string) error {
+	r, err := parseFrameRate(rate)
+	if err != nil {
+		return err
+	}
+	frames, err := r.parseTimecode(start)
+	if err != nil {
+		return err
+	}
+	timecodeRate = &r
+	startTimecode = frames
+	return nil
+}
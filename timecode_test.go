@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimecode(t *testing.T) {
+	df, _ := parseFrameRate("29.97df")
+	ndf, _ := parseFrameRate("29.97ndf")
+	film, _ := parseFrameRate("23.976")
+	pal, _ := parseFrameRate("25")
+	tests := []struct {
+		rate     frameRate
+		frames   int
+		expected string
+	}{
+		{df, 0, "00:00:00;00"},
+		{df, 1799, "00:00:59;29"},
+		{df, 1800, "00:01:00;02"},
+		{df, 17982, "00:10:00;00"},
+		{df, 107892, "01:00:00;00"},
+		{df, -1, "23:59:59;29"},
+		{ndf, 107892, "00:59:56:12"},
+		{film, 86400, "01:00:00:00"},
+		{pal, 90000 + 24, "01:00:00:24"},
+	}
+	for _, test := range tests {
+		tc := test.rate.timecode(test.frames)
+		if tc != test.expected {
+			t.Errorf("%s: expected frame %d to be %s, got %s", test.rate, test.frames, test.expected, tc)
+		}
+		frames, err := test.rate.parseTimecode(tc)
+		if err != nil {
+			t.Errorf("%s: failed to parse %s: %s", test.rate, tc, err)
+		} else if test.frames >= 0 && frames != test.frames {
+			t.Errorf("%s: expected %s to be frame %d, got %d", test.rate, tc, test.frames, frames)
+		}
+	}
+
+	if _, err := df.parseTimecode("00:01:00;00"); err == nil {
+		t.Errorf("Expected dropped frame label to be rejected")
+	}
+	if _, err := parseFrameRate("29.97"); err == nil {
+		t.Errorf("Expected ambiguous frame rate to be rejected")
+	}
+}
+
+func TestTimecodeFrameRoundTrip(t *testing.T) {
+	for _, r := range frameRates {
+		for _, frames := range []int{0, 1, 1799, 1800, 17981, 17982, 100000, 1000000} {
+			got, err := r.parseTimecode(r.timecode(frames))
+			if err != nil || got != frames {
+				t.Errorf("%s: frame %d became %d (%v)", r, frames, got, err)
+			}
+			if d := r.durationToFrames(r.framesToDuration(frames)); d != frames {
+				t.Errorf("%s: frame %d duration became frame %d", r, frames, d)
+			}
+		}
+	}
+}
+
+func TestFormatSyncedTime(t *testing.T) {
+	defer func() { timecodeRate, startTimecode = nil, 0 }()
+
+	if err := setTimecodeFormat("25", "01:00:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	if tc := formatSyncedTime(2*time.Second + 100*time.Millisecond); tc != "01:00:02:02" {
+		t.Errorf("Expected 01:00:02:02, got %s", tc)
+	}
+	if tc := formatSyncedTime(-time.Second); tc != "00:59:59:00" {
+		t.Errorf("Expected 00:59:59:00, got %s", tc)
+	}
+	if err := setTimecodeFormat("30", "00:00:00:30"); err == nil {
+		t.Errorf("Expected out of range start timecode to be rejected")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	// Length of the overlapping frames used for time stretching, in samples.
+	stretchFrameSize = 1024
+	// How far from its nominal position a frame may be moved to line up with the previous frame, in samples.
+	stretchTolerance = 256
+	// Only every nth sample is compared when searching for the best frame position.
+	stretchSearchStride = 4
+)
+
+// Changes the tempo of the samples by the given speed factor without changing their pitch,
+// using WSOLA (waveform similarity based overlap-add). A speed of 2 halves the length of the audio.
+func timeStretch(samples []int32, speed float64) []int32 {
+	if speed == 1 || len(samples) < stretchFrameSize {
+		return samples
+	}
+
+	synthesisHop := stretchFrameSize / 2
+	analysisHop := float64(synthesisHop) * speed
+
+	window := make([]float64, stretchFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(stretchFrameSize))
+	}
+
+	out := make([]float64, int(float64(len(samples))/speed)+stretchFrameSize)
+	prevPos := 0
+	outPos := 0
+	for k := 0; ; k++ {
+		nominal := int(float64(k) * analysisHop)
+		pos := nominal
+		if k > 0 {
+			pos = bestStretchPosition(samples, prevPos+synthesisHop, nominal)
+		}
+		if pos+stretchFrameSize > len(samples) || outPos+stretchFrameSize > len(out) {
+			break
+		}
+		for i := 0; i < stretchFrameSize; i++ {
+			out[outPos+i] += float64(samples[pos+i]) * window[i]
+		}
+		prevPos = pos
+		outPos += synthesisHop
+	}
+
+	length := clamp(outPos+synthesisHop, 0, len(out))
+	stretched := make([]int32, length)
+	for i := range stretched {
+		stretched[i] = int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, out[i])))
+	}
+	return stretched
+}
+
+// Finds the position within the tolerance of nominal where the frame is most similar to the
+// natural continuation of the previous frame, starting at continuation.
+func bestStretchPosition(samples []int32, continuation, nominal int) int {
+	overlap := stretchFrameSize / 2
+	if continuation+overlap > len(samples) {
+		return nominal
+	}
+
+	best := nominal
+	bestScore := math.Inf(-1)
+	for pos := nominal - stretchTolerance; pos <= nominal+stretchTolerance; pos++ {
+		if pos < 0 || pos+stretchFrameSize > len(samples) {
+			continue
+		}
+		score := 0.0
+		for i := 0; i < overlap; i += stretchSearchStride {
+			score += float64(samples[continuation+i]) * float64(samples[pos+i])
+		}
+		if score > bestScore {
+			best = pos
+			bestScore = score
+		}
+	}
+	return best
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func sineWave(freq float64, length int) []int32 {
+	samples := make([]int32, length)
+	for i := range samples {
+		samples[i] = int32(math.Sin(2*math.Pi*freq*float64(i)/sampleRate) * math.MaxInt32 / 2)
+	}
+	return samples
+}
+
+func countZeroCrossings(samples []int32) int {
+	n := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTimeStretch(t *testing.T) {
+	samples := sineWave(440, sampleRate*2)
+
+	for _, speed := range []float64{0.5, 1.5, 2} {
+		stretched := timeStretch(samples, speed)
+
+		expectLength := float64(len(samples)) / speed
+		if math.Abs(float64(len(stretched))-expectLength) > stretchFrameSize*2 {
+			t.Errorf("speed %v: expected length around %v, got %d", speed, expectLength, len(stretched))
+		}
+
+		// Pitch is preserved when the number of zero crossings per sample stays the same.
+		middle := stretched[stretchFrameSize : len(stretched)-stretchFrameSize]
+		freq := float64(countZeroCrossings(middle)) / 2 / float64(len(middle)) * sampleRate
+		if math.Abs(freq-440) > 10 {
+			t.Errorf("speed %v: expected pitch to be preserved at 440 Hz, got %v Hz", speed, freq)
+		}
+	}
+}
+
+func TestTimeStretchNormalSpeed(t *testing.T) {
+	samples := sineWave(440, sampleRate)
+	if len(timeStretch(samples, 1)) != len(samples) {
+		t.Errorf("Expected samples to be unchanged at normal speed")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+)
+
+// Whether the take list shows the sync takes instead of the takes of the selected chunk.
+var viewingSyncTakes bool = false
+
+// A take that is waiting to be moved or copied to the chunk (or sync takes) that gets selected next.
+type takeTransfer struct {
+	copy      bool
+	fromSync  bool
+	fromChunk uint
+	fromIndex int
+}
+
+var pendingTransfer *takeTransfer
+
+func (t *takeTransfer) desc() string {
+	if t.copy {
+		return "Copy Take"
+	}
+	return "Move Take"
+}
+
+// Picks up the selected take, so it can be moved or copied to another chunk.
+func beginTransfer(copy bool) {
+	if getSelectedTake() == nil {
+		return
+	}
+	pendingTransfer = &takeTransfer{
+		copy:      copy,
+		fromSync:  viewingSyncTakes,
+		fromChunk: selectedChunk,
+		fromIndex: selectedTake,
+	}
+	splitPoints = nil
+}
+
+// Moves or copies the pending take to the currently selected chunk, or to the sync takes if they are shown.
+// Takes moved to the sync takes become sync takes, and sync takes moved to a chunk become unmarked takes.
+func completeTransfer() error {
+	t := pendingTransfer
+	if t == nil {
+		return errors.New("No take to move")
+	}
+	pendingTransfer = nil
+
+	doc := &currentSession.Doc
+	from := &doc.syncTakes
+	if !t.fromSync {
+		from = &doc.GetChunk(int(t.fromChunk)).Takes
+	}
+	if t.fromIndex >= len(*from) {
+		return errors.New("The take no longer exists")
+	}
+	to := selectedTakes()
+	if !t.copy && from == to {
+		return errors.New("The take is already there")
+	}
+
+	syncBefore, hadSync := doc.firstSyncSpan()
+
+	recordHistory(t.desc())
+	take := copyTakes((*from)[t.fromIndex : t.fromIndex+1])[0]
+	// The destination may already have a circled take.
+	take.Circled = false
+	if viewingSyncTakes {
+		take.Mark = Sync
+	} else if take.Mark == Sync {
+		take.Mark = Unmarked
+		take.Camera = ""
+	}
+	if !t.copy {
+		*from = deleteTake(*from, t.fromIndex)
+	}
+	*to = append(*to, take)
+	selectedTake = len(*to) - 1
+
+	var err error
+	if syncAfter, hasSync := doc.firstSyncSpan(); hasSync != hadSync || syncAfter != syncBefore {
+		err = currentSession.updateSyncOffset()
+	}
+	if cameraErr := currentSession.updateCameraSyncs(); err == nil {
+		err = cameraErr
+	}
+	return err
+}
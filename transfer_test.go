@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompleteTransfer(t *testing.T) {
+	currentSession = Session{
+		Audio: make([]int32, sampleRate*10),
+		Doc:   parseDoc("# Test\nchunk 1\n\nchunk 2"),
+	}
+	currentSession.Audio[sampleRate*3] = 1000
+	undoStack, redoStack = nil, nil
+	viewingSyncTakes = false
+	chunk := currentSession.Doc.GetChunk(0)
+	chunk.Takes = []Take{
+		{TimeSpan: TimeSpan{Start: time.Second, End: 2 * time.Second}, Mark: Good},
+		{TimeSpan: TimeSpan{Start: 2 * time.Second, End: 4 * time.Second}, Mark: Bad},
+	}
+
+	// move the first take to the second chunk
+	selectedChunk, selectedTake = 0, 0
+	beginTransfer(false)
+	selectedChunk = 1
+	if err := completeTransfer(); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.GetChunk(0).Takes) != 1 || len(currentSession.Doc.GetChunk(1).Takes) != 1 {
+		t.Fatalf("Expected take to be moved")
+	}
+	if currentSession.Doc.GetChunk(1).Takes[0].Start != time.Second || selectedTake != 0 {
+		t.Errorf("Incorrect take moved")
+	}
+
+	// copy the remaining take of the first chunk to the sync takes
+	selectedChunk, selectedTake = 0, 0
+	beginTransfer(true)
+	viewingSyncTakes = true
+	if err := completeTransfer(); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.GetChunk(0).Takes) != 1 || len(currentSession.Doc.syncTakes) != 1 {
+		t.Fatalf("Expected take to be copied")
+	}
+	if currentSession.Doc.syncTakes[0].Mark != Sync {
+		t.Errorf("Expected copied take to become a sync take")
+	}
+	if currentSession.Doc.SyncOffset != 3*time.Second {
+		t.Errorf("Expected sync offset to be updated, got %s", currentSession.Doc.SyncOffset)
+	}
+
+	// moving a take to where it already is fails
+	selectedTake = 0
+	beginTransfer(false)
+	if err := completeTransfer(); err == nil {
+		t.Errorf("Expected moving a take to its own list to fail")
+	}
+
+	// move the sync take back to a chunk
+	beginTransfer(false)
+	viewingSyncTakes = false
+	selectedChunk = 1
+	if err := completeTransfer(); err != nil {
+		t.Fatal(err)
+	}
+	if len(currentSession.Doc.syncTakes) != 0 || currentSession.Doc.SyncOffset != 0 {
+		t.Errorf("Expected sync take and sync offset to be removed")
+	}
+	if currentSession.Doc.GetChunk(1).Takes[1].Mark != Unmarked {
+		t.Errorf("Expected take moved out of the sync takes to be unmarked")
+	}
+}
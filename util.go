@@ -3,6 +3,9 @@ package main
 import (
 	"fmt"
 	"image"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mum4k/termdash/cell"
@@ -26,9 +29,50 @@ func valmap(x, in_min, in_max, out_min, out_max int) int {
 
 // TODO: alias time.Duration to our own type, and make this the String() function for that type
 func Timestamp(t *time.Duration) string {
+	if *t < 0 {
+		neg := -*t
+		return "-" + Timestamp(&neg)
+	}
 	return fmt.Sprintf("%02d:%02d:%02d.%03d", int32(t.Hours()), int32(t.Minutes())%60, int32(t.Seconds())%60, t.Milliseconds()%1000)
 }
 
+// Parses a timestamp in the format written by Timestamp. Hours and minutes may be omitted.
+func ParseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 || s == "" {
+		return 0, fmt.Errorf("Invalid timestamp: %s", s)
+	}
+	var d time.Duration
+	for i, part := range parts {
+		var unit time.Duration
+		switch len(parts) - i {
+		case 3:
+			unit = time.Hour
+		case 2:
+			unit = time.Minute
+		default:
+			seconds, err := strconv.ParseFloat(part, 64)
+			if err != nil || seconds < 0 {
+				return 0, fmt.Errorf("Invalid timestamp: %s", s)
+			}
+			d += time.Duration(math.Round(seconds*float64(time.Second/time.Millisecond))) * time.Millisecond
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("Invalid timestamp: %s", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
 func DrawCells(cvs *canvas.Canvas, cells []*buffer.Cell, x, y int) {
 	for i, c := range cells {
 		cvs.SetCell(image.Point{
@@ -84,15 +128,3 @@ func markdownFontModifiers(cells []*buffer.Cell) []*buffer.Cell {
 	}
 	return mdcells
 }
-
-func indexOfMaxInt32(arr []int32) int {
-	var idx int
-	var m int32
-	for i, e := range arr {
-		if i == 0 || e > m {
-			idx = i
-			m = e
-		}
-	}
-	return idx
-}
@@ -3,6 +3,7 @@ package main
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/mum4k/termdash/cell"
 	"github.com/mum4k/termdash/private/canvas/buffer"
@@ -18,3 +19,41 @@ func TestMarkdownFontModifiers(t *testing.T) {
 		t.Errorf("Cells were not equal: len %d != len %d", len(cells), len(expect_cells))
 	}
 }
+
+func TestTimestamp(t *testing.T) {
+	d := time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond
+	if ts := Timestamp(&d); ts != "01:02:03.045" {
+		t.Errorf("Incorrect timestamp: %s", ts)
+	}
+	d = -1500 * time.Millisecond
+	if ts := Timestamp(&d); ts != "-00:00:01.500" {
+		t.Errorf("Incorrect negative timestamp: %s", ts)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := map[string]time.Duration{
+		"01:02:03.045":  time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond,
+		"02:03.5":       2*time.Minute + 3*time.Second + 500*time.Millisecond,
+		"7":             7 * time.Second,
+		"-00:00:01.500": -1500 * time.Millisecond,
+	}
+	for input, expect := range tests {
+		d, err := ParseTimestamp(input)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %s", input, err)
+		} else if d != expect {
+			t.Errorf("Incorrect timestamp parsed from %s: %s", input, d)
+		}
+	}
+	for _, input := range []string{"", "a:b", "1:2:3:4", "00:-1:00"} {
+		if _, err := ParseTimestamp(input); err == nil {
+			t.Errorf("Expected %q to fail to parse", input)
+		}
+	}
+
+	d := 3*time.Hour + 59*time.Minute + 59*time.Second + 999*time.Millisecond
+	if parsed, _ := ParseTimestamp(Timestamp(&d)); parsed != d {
+		t.Errorf("Expected timestamp to survive a round trip, got %s", parsed)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"os"
+	"time"
+
+	"github.com/go-audio/wav"
+)
+
+const (
+	// The reference is first lined up using the audio's envelope, measured in blocks of this length.
+	envelopeBlock = time.Millisecond
+	// Length of the part of the reference used to refine the alignment sample by sample.
+	refineLength = time.Second
+)
+
+// Audio that the session is synced against, like the scratch audio recorded by a camera.
+type referenceAudio struct {
+	Samples    []float64
+	SampleRate int
+}
+
+// Path to a wav file with the reference audio to sync against, if any.
+var syncReferencePath string
+
+func readReferenceAudio(path string) (referenceAudio, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return referenceAudio{}, err
+	}
+	defer f.Close()
+
+	d := wav.NewDecoder(f)
+	if !d.IsValidFile() {
+		return referenceAudio{}, errors.New("Not a valid wav file: " + path)
+	}
+	buf, err := d.FullPCMBuffer()
+	if err != nil {
+		return referenceAudio{}, err
+	}
+	channels := buf.Format.NumChannels
+	scale := math.Pow(2, float64(buf.SourceBitDepth-1))
+	ref := referenceAudio{
+		Samples:    make([]float64, len(buf.Data)/channels),
+		SampleRate: buf.Format.SampleRate,
+	}
+	for i := range ref.Samples {
+		for c := 0; c < channels; c++ {
+			ref.Samples[i] += float64(buf.Data[i*channels+c]) / scale / float64(channels)
+		}
+	}
+	return ref, nil
+}
+
+// In-place iterative radix-2 fast fourier transform. The length of x must be a power of 2.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*wk
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+	if inverse {
+		for i := range x {
+			x[i] /= complex(float64(n), 0)
+		}
+	}
+}
+
+// Cross-correlates a against b using the FFT. The result at index lag+len(b)-1 is the correlation
+// of a with b shifted lag samples later, so that a[i+lag] lines up with b[i].
+func crossCorrelate(a, b []float64) []float64 {
+	n := 1
+	for n < len(a)+len(b) {
+		n <<= 1
+	}
+	fa := make([]complex128, n)
+	fb := make([]complex128, n)
+	for i, v := range a {
+		fa[i] = complex(v, 0)
+	}
+	for i, v := range b {
+		fb[i] = complex(v, 0)
+	}
+	fft(fa, false)
+	fft(fb, false)
+	for i := range fa {
+		fa[i] *= cmplx.Conj(fb[i])
+	}
+	fft(fa, true)
+
+	// rotate negative lags to the start
+	result := make([]float64, len(a)+len(b)-1)
+	for i := range result {
+		lag := i - (len(b) - 1)
+		result[i] = real(fa[(lag+n)%n])
+	}
+	return result
+}
+
+// Measures the average absolute level of the audio in blocks of envelopeBlock, with the mean removed.
+func envelope(samples []float64, rate int) []float64 {
+	blocks := int(time.Duration(len(samples)) * time.Second / time.Duration(rate) / envelopeBlock)
+	env := make([]float64, blocks)
+	mean := 0.0
+	for b := range env {
+		start := b * rate / 1000
+		end := (b + 1) * rate / 1000
+		for _, s := range samples[start:end] {
+			env[b] += math.Abs(s)
+		}
+		env[b] /= float64(end - start)
+		mean += env[b] / float64(blocks)
+	}
+	for b := range env {
+		env[b] -= mean
+	}
+	return env
+}
+
+// Finds where the reference audio starts in the recorded audio, which may be before the recording
+// started. Also returns how much the best alignment stands out from the next best, from 0 to 1.
+func findReferenceOffset(recorded []int32, ref referenceAudio) (time.Duration, float64, error) {
+	audio := make([]float64, len(recorded))
+	for i, s := range recorded {
+		audio[i] = float64(s) / math.MaxInt32
+	}
+	envAudio := envelope(audio, sampleRate)
+	envRef := envelope(ref.Samples, ref.SampleRate)
+	if len(envAudio) == 0 || len(envRef) == 0 {
+		return 0, 0, errors.New("Not enough audio to sync")
+	}
+
+	// line up the envelopes to the nearest block
+	corr := crossCorrelate(envAudio, envRef)
+	best := 0
+	for i, c := range corr {
+		if c > corr[best] {
+			best = i
+		}
+	}
+	if corr[best] <= 0 {
+		return 0, 0, errors.New("The reference audio does not match the recording")
+	}
+	// the next best alignment that isn't part of the same peak
+	runnerUp := 0.0
+	for i, c := range corr {
+		if (i < best-50 || i > best+50) && c > runnerUp {
+			runnerUp = c
+		}
+	}
+	quality := 1 - runnerUp/corr[best]
+	coarse := time.Duration(best-(len(envRef)-1)) * envelopeBlock
+
+	// refine to the nearest sample, using the loudest part of the reference
+	refStart := durationToSamples(ref.SampleRate, loudestSegment(envRef, refineLength))
+	refStartTime := samplesToDuration(ref.SampleRate, refStart)
+	segment := resampleSegment(ref, refStart, refineLength)
+	center := durationToSamples(sampleRate, coarse+refStartTime)
+	searched := durationToSamples(sampleRate, 2*envelopeBlock)
+	bestStart := center
+	bestScore := math.Inf(-1)
+	for start := center - searched; start <= center+searched; start++ {
+		if start < 0 || start+len(segment) > len(audio) {
+			continue
+		}
+		score := 0.0
+		for i, s := range segment {
+			score += s * audio[start+i]
+		}
+		if score > bestScore {
+			bestScore = score
+			bestStart = start
+		}
+	}
+
+	return samplesToDuration(sampleRate, bestStart) - refStartTime, quality, nil
+}
+
+// Returns the start of the loudest part of the envelope that is length long.
+func loudestSegment(env []float64, length time.Duration) time.Duration {
+	blocks := int(length / envelopeBlock)
+	if blocks >= len(env) {
+		return 0
+	}
+	sum := 0.0
+	for _, e := range env[:blocks] {
+		sum += e
+	}
+	best, bestSum := 0, sum
+	for b := blocks; b < len(env); b++ {
+		sum += env[b] - env[b-blocks]
+		if sum > bestSum {
+			best, bestSum = b-blocks+1, sum
+		}
+	}
+	return time.Duration(best) * envelopeBlock
+}
+
+// Resamples part of the reference audio, starting at the given reference sample, to the recording's
+// sample rate with linear interpolation.
+func resampleSegment(ref referenceAudio, start int, length time.Duration) []float64 {
+	out := make([]float64, durationToSamples(sampleRate, length))
+	step := float64(ref.SampleRate) / sampleRate
+	for i := range out {
+		pos := float64(start) + float64(i)*step
+		j := int(pos)
+		if j+1 >= len(ref.Samples) {
+			return out[:i]
+		}
+		frac := pos - float64(j)
+		out[i] = ref.Samples[j]*(1-frac) + ref.Samples[j+1]*frac
+	}
+	return out
+}
+
+// Syncs the session to the reference audio, replacing any sync from sync takes. Returns the quality of the match.
+func (s *Session) syncToReference(ref referenceAudio) (float64, error) {
+	offset, quality, err := findReferenceOffset(s.Audio, ref)
+	if err != nil {
+		return 0, err
+	}
+	if quality < minSyncConfidence {
+		return quality, errors.New("The reference audio could not be lined up with the recording clearly")
+	}
+	s.Doc.SyncOffset = offset
+	s.Doc.SyncConfidence = quality
+	s.Doc.SyncDrift = 0
+	s.Doc.SyncManual = false
+	s.Doc.syncCandidates = []time.Duration{offset}
+	return quality, nil
+}
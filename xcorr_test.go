@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestFFT(t *testing.T) {
+	x := make([]complex128, 16)
+	for i := range x {
+		x[i] = complex(rand.Float64(), 0)
+	}
+	dft := make([]complex128, len(x))
+	for k := range dft {
+		for n, v := range x {
+			dft[k] += v * cmplx.Rect(1, -2*math.Pi*float64(k*n)/float64(len(x)))
+		}
+	}
+
+	y := append([]complex128(nil), x...)
+	fft(y, false)
+	for k := range y {
+		if cmplx.Abs(y[k]-dft[k]) > 1e-9 {
+			t.Fatalf("FFT differs from DFT at %d: %v != %v", k, y[k], dft[k])
+		}
+	}
+	fft(y, true)
+	for k := range y {
+		if cmplx.Abs(y[k]-x[k]) > 1e-9 {
+			t.Fatalf("Inverse FFT did not restore input at %d", k)
+		}
+	}
+}
+
+// Creates noise with a changing level, so that it has a distinct envelope.
+func modulatedNoise(length int) []int32 {
+	r := rand.New(rand.NewSource(1))
+	samples := make([]int32, length)
+	level := 0.5
+	for i := range samples {
+		if i%2000 == 0 {
+			level = r.Float64()
+		}
+		samples[i] = int32((r.Float64()*2 - 1) * level * math.MaxInt32 / 2)
+	}
+	return samples
+}
+
+func toReference(samples []int32) referenceAudio {
+	ref := referenceAudio{SampleRate: sampleRate}
+	for _, s := range samples {
+		ref.Samples = append(ref.Samples, float64(s)/math.MaxInt32)
+	}
+	return ref
+}
+
+func TestFindReferenceOffset(t *testing.T) {
+	recorded := modulatedNoise(sampleRate * 6)
+
+	t.Run("reference starts during the recording", func(t *testing.T) {
+		start := durationToSamples(sampleRate, 1234500*time.Microsecond)
+		ref := toReference(recorded[start : start+sampleRate*3])
+		offset, quality, err := findReferenceOffset(recorded, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if durationToSamples(sampleRate, offset) != start {
+			t.Errorf("Expected offset of %d samples, got %d", start, durationToSamples(sampleRate, offset))
+		}
+		if quality < minSyncConfidence {
+			t.Errorf("Expected a clear match, got quality %v", quality)
+		}
+	})
+
+	t.Run("reference at a different sample rate", func(t *testing.T) {
+		start := sampleRate * 2
+		ref := referenceAudio{SampleRate: sampleRate / 2}
+		for i := start; i < start+sampleRate*3; i += 2 {
+			ref.Samples = append(ref.Samples, (float64(recorded[i])+float64(recorded[i+1]))/2/math.MaxInt32)
+		}
+		offset, _, err := findReferenceOffset(recorded, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := durationToSamples(sampleRate, offset); n < start-2 || n > start+2 {
+			t.Errorf("Expected offset of around %d samples, got %d", start, n)
+		}
+	})
+
+	t.Run("reference starts before the recording", func(t *testing.T) {
+		lead := make([]int32, sampleRate/2)
+		for i := range lead {
+			lead[i] = int32(rand.Intn(1000))
+		}
+		ref := toReference(append(lead, recorded[:sampleRate*3]...))
+		offset, _, err := findReferenceOffset(recorded, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset != -500*time.Millisecond {
+			t.Errorf("Expected offset of -500ms, got %s", offset)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type xmemlDocument struct {
+	XMLName  xml.Name      `xml:"xmeml"`
+	Version  string        `xml:"version,attr"`
+	Sequence xmemlSequence `xml:"sequence"`
+}
+
+type xmemlRate struct {
+	Timebase int    `xml:"timebase"`
+	NTSC     string `xml:"ntsc"`
+}
+
+type xmemlSequence struct {
+	ID       string        `xml:"id,attr"`
+	Name     string        `xml:"name"`
+	Duration int           `xml:"duration"`
+	Rate     xmemlRate     `xml:"rate"`
+	Timecode xmemlTimecode `xml:"timecode"`
+	Format   xmemlSamples  `xml:"media>audio>format>samplecharacteristics"`
+	Tracks   []xmemlTrack  `xml:"media>audio>track"`
+	Markers  []xmemlMarker `xml:"marker"`
+}
+
+type xmemlTrack struct {
+	Clips []xmemlClip `xml:"clipitem"`
+}
+
+type xmemlTimecode struct {
+	Rate          xmemlRate `xml:"rate"`
+	String        string    `xml:"string"`
+	Frame         int       `xml:"frame"`
+	DisplayFormat string    `xml:"displayformat"`
+}
+
+type xmemlSamples struct {
+	Depth      int `xml:"depth"`
+	SampleRate int `xml:"samplerate"`
+}
+
+type xmemlClip struct {
+	ID          string    `xml:"id,attr"`
+	Name        string    `xml:"name"`
+	Enabled     string    `xml:"enabled"`
+	Duration    int       `xml:"duration"`
+	Rate        xmemlRate `xml:"rate"`
+	Start       int       `xml:"start"`
+	End         int       `xml:"end"`
+	In          int       `xml:"in"`
+	Out         int       `xml:"out"`
+	File        xmemlFile `xml:"file"`
+	SourceTrack struct {
+		MediaType  string `xml:"mediatype"`
+		TrackIndex int    `xml:"trackindex"`
+	} `xml:"sourcetrack"`
+	Comment string `xml:"comments>mastercomment1,omitempty"`
+}
+
+// A media file, which is only described in full the first time it is referenced.
+type xmemlFile struct {
+	ID       string          `xml:"id,attr"`
+	Name     string          `xml:"name,omitempty"`
+	PathURL  string          `xml:"pathurl,omitempty"`
+	Rate     *xmemlRate      `xml:"rate,omitempty"`
+	Duration int             `xml:"duration,omitempty"`
+	Timecode *xmemlTimecode  `xml:"timecode,omitempty"`
+	Media    *xmemlFileAudio `xml:"media>audio,omitempty"`
+}
+
+type xmemlFileAudio struct {
+	Samples      xmemlSamples `xml:"samplecharacteristics"`
+	ChannelCount int          `xml:"channelcount"`
+}
+
+type xmemlMarker struct {
+	Name    string `xml:"name"`
+	Comment string `xml:"comment"`
+	In      int    `xml:"in"`
+	Out     int    `xml:"out"`
+}
+
+func xmemlRateOf(rate frameRate) xmemlRate {
+	ntsc := "FALSE"
+	if rate.den == 1001 {
+		ntsc = "TRUE"
+	}
+	return xmemlRate{Timebase: rate.timebase(), NTSC: ntsc}
+}
+
+// Writes a Final Cut Pro 7 XML sequence, as imported by Premiere, with the chosen take of every chunk laid out back
+// to back in script order on one audio track. Every header and take gets a sequence marker. Takes marked bad are
+// included as disabled clips on a second track if exportRejected is set.
+func writeXMEML(w io.Writer, s *Session) error {
+	clips, err := s.programClips(exportRejected)
+	if err != nil {
+		return err
+	}
+	audioURL, err := s.audioURL()
+	if err != nil {
+		return err
+	}
+	rate := exportFrameRate()
+	xrate := xmemlRateOf(rate)
+	displayFormat := "NDF"
+	if rate.dropFrame {
+		displayFormat = "DF"
+	}
+	tcStart, err := rate.parseTimecode(timelineStart)
+	if err != nil {
+		return err
+	}
+	fileDuration := rate.durationToFrames(samplesToDuration(sampleRate, len(s.Audio)))
+	mediaStart, _ := s.mediaStartFrame()
+	fileTimecode := xmemlTimecode{
+		Rate:          xrate,
+		String:        rate.timecode(mediaStart),
+		Frame:         mediaStart,
+		DisplayFormat: displayFormat,
+	}
+
+	seq := xmemlSequence{
+		ID:   "sequence-1",
+		Name: fmt.Sprintf("Session %d", s.Id),
+		Rate: xrate,
+		Timecode: xmemlTimecode{
+			Rate:          xrate,
+			String:        rate.timecode(tcStart),
+			Frame:         tcStart,
+			DisplayFormat: displayFormat,
+		},
+		Format: xmemlSamples{Depth: 32, SampleRate: sampleRate},
+	}
+
+	program, rejected, duration := layoutClips(clips)
+	seq.Duration = duration
+	id := 0
+	clipItem := func(c timelineClip) xmemlClip {
+		id++
+		clip := xmemlClip{
+			ID:       fmt.Sprintf("clipitem-%d", id),
+			Name:     fmt.Sprintf("%s %d take %d", c.Header, c.HeaderChunk, c.TakeIndex),
+			Enabled:  "TRUE",
+			Duration: fileDuration,
+			Rate:     xrate,
+			Start:    c.Start,
+			End:      c.End(),
+			// in and out points count from the start of the file, which starts at its timecode
+			In:      c.In - mediaStart,
+			Out:     c.Out - mediaStart,
+			File:    xmemlFile{ID: "file-1"},
+			Comment: c.Take.Note,
+		}
+		if c.Rejected {
+			clip.Enabled = "FALSE"
+		}
+		if id == 1 {
+			clip.File.Name = "audio.wav"
+			clip.File.PathURL = audioURL
+			clip.File.Rate = &xrate
+			clip.File.Duration = fileDuration
+			clip.File.Timecode = &fileTimecode
+			clip.File.Media = &xmemlFileAudio{
+				Samples:      xmemlSamples{Depth: 32, SampleRate: sampleRate},
+				ChannelCount: 1,
+			}
+		}
+		clip.SourceTrack.MediaType = "audio"
+		clip.SourceTrack.TrackIndex = 1
+		seq.Markers = append(seq.Markers, xmemlMarker{
+			Name:    clip.Name,
+			Comment: strings.Join(takeKeywords(c.Take), ", "),
+			In:      clip.Start,
+			Out:     -1,
+		})
+		return clip
+	}
+
+	var track xmemlTrack
+	lastHeader := ""
+	for i, c := range program {
+		if i == 0 || c.Header != lastHeader {
+			seq.Markers = append(seq.Markers, xmemlMarker{Name: c.Header, In: c.Start, Out: -1})
+		}
+		track.Clips = append(track.Clips, clipItem(c))
+		lastHeader = c.Header
+	}
+	seq.Tracks = append(seq.Tracks, track)
+	if len(rejected) > 0 {
+		var rejectedTrack xmemlTrack
+		for _, c := range rejected {
+			rejectedTrack.Clips = append(rejectedTrack.Clips, clipItem(c))
+		}
+		seq.Tracks = append(seq.Tracks, rejectedTrack)
+	}
+
+	_, err = io.WriteString(w, xml.Header+"<!DOCTYPE xmeml>\n")
+	if err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	err = e.Encode(xmemlDocument{Version: "4", Sequence: seq})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteXMEML(t *testing.T) {
+	useExportTestSession(t)
+	exportRejected = true
+	currentSession.Doc.GetChunk(2).Takes[1].Rating = 2
+
+	var b bytes.Buffer
+	if err := writeXMEML(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header+"<!DOCTYPE xmeml>") {
+		t.Errorf("Missing doctype: %s", b.String())
+	}
+	var doc xmemlDocument
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	seq := doc.Sequence
+	if seq.Rate.Timebase != 30 || seq.Timecode.Frame != 108000 || seq.Timecode.String != "01:00:00:00" {
+		t.Errorf("Incorrect sequence timing: %+v %+v", seq.Rate, seq.Timecode)
+	}
+
+	// the rejected take is on its own track, at the position of its chunk's chosen take
+	expected := [][]xmemlClip{
+		{
+			{Name: "Intro 0 take 0", Enabled: "TRUE", Start: 0, End: 60, In: 60, Out: 120},
+			{Name: "Outro 0 take 1", Enabled: "TRUE", Start: 60, End: 105, In: 360, Out: 405},
+		},
+		{
+			{Name: "Intro 0 take 1", Enabled: "FALSE", Start: 0, End: 60, In: 150, Out: 210},
+		},
+	}
+	if len(seq.Tracks) != len(expected) {
+		t.Fatalf("Expected %d tracks, got %d", len(expected), len(seq.Tracks))
+	}
+	for i, track := range seq.Tracks {
+		if len(track.Clips) != len(expected[i]) {
+			t.Fatalf("Track %d: expected %d clips, got %d", i, len(expected[i]), len(track.Clips))
+		}
+		for j, c := range track.Clips {
+			e := expected[i][j]
+			if c.Name != e.Name || c.Enabled != e.Enabled || c.Start != e.Start || c.End != e.End || c.In != e.In || c.Out != e.Out {
+				t.Errorf("Track %d clip %d: expected %+v, got %+v", i, j, e, c)
+			}
+			if c.File.ID != "file-1" {
+				t.Errorf("Track %d clip %d: incorrect file %+v", i, j, c.File)
+			}
+		}
+	}
+	program := seq.Tracks[0].Clips
+	if !strings.HasSuffix(program[0].File.PathURL, "/sessions/7/audio.wav") || program[1].File.PathURL != "" {
+		t.Errorf("The file should only be described by the first clip: %+v %+v", program[0].File, program[1].File)
+	}
+	if tc := program[0].File.Timecode; tc == nil || tc.String != "23:59:59:00" || tc.Frame != 2591970 {
+		t.Errorf("The file should start at the timecode of the audio before the sync peak: %+v", tc)
+	}
+	if seq.Duration != 105 {
+		t.Errorf("Incorrect sequence duration: %d", seq.Duration)
+	}
+
+	var markers []string
+	for _, m := range seq.Markers {
+		markers = append(markers, m.Name)
+	}
+	if strings.Join(markers, "|") != "Intro|Intro 0 take 0|Outro|Outro 0 take 1|Intro 0 take 1" {
+		t.Errorf("Incorrect markers: %v", markers)
+	}
+	if seq.Markers[3].In != 60 || seq.Markers[3].Comment != "bad, circled, ★★" {
+		t.Errorf("Incorrect take marker: %+v", seq.Markers[3])
+	}
+
+	exportRejected = false
+	b.Reset()
+	if err := writeXMEML(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	without := xmemlDocument{}
+	if err := xml.Unmarshal(b.Bytes(), &without); err != nil {
+		t.Fatal(err)
+	}
+	if len(without.Sequence.Tracks) != 1 || !reflect.DeepEqual(without.Sequence.Tracks[0], seq.Tracks[0]) {
+		t.Errorf("Rejected takes should not change the program, got %+v", without.Sequence.Tracks)
+	}
+}
+
+func TestWriteXMEMLStartTimecode(t *testing.T) {
+	useExportTestSession(t)
+	setTimecodeFormat("25", "10:00:00:00")
+
+	var b bytes.Buffer
+	if err := writeXMEML(&b, &currentSession); err != nil {
+		t.Fatal(err)
+	}
+	var doc xmemlDocument
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	// in and out points stay relative to the file, which starts a second before the start timecode
+	clips := doc.Sequence.Tracks[0].Clips
+	if tc := clips[0].File.Timecode; tc == nil || tc.String != "09:59:59:00" || tc.Frame != 899975 {
+		t.Errorf("Incorrect file timecode: %+v", tc)
+	}
+	if clips[0].In != 50 || clips[0].Out != 100 || clips[1].In != 300 || clips[1].Out != 337 {
+		t.Errorf("Incorrect clip in and out points: %+v", clips)
+	}
+}